@@ -11,6 +11,7 @@ type CommandSet struct {
 	SaveSettings string // 保存设置 AT&W
 	LoadProfile  string // 加载配置文件 AT&Z<profile>
 	SaveProfile  string // 保存到配置文件 AT&W<profile>
+	Charset      string // 查询/设置 TE 字符集 AT+CSCS
 
 	// 设备身份信息
 	IMEI         string // 查询 IMEI AT+CGSN
@@ -26,7 +27,11 @@ type CommandSet struct {
 	NetworkMode string // 查询/设置网络模式 AT+CNMP
 	NetworkReg  string // 查询/设置网络注册状态及通知 AT+CREG
 	GPRSReg     string // 查询/设置 GPRS 注册状态及通知 AT+CGREG
+	EPSReg      string // 查询/设置 EPS 注册状态及通知 AT+CEREG
 	Signal      string // 查询信号质量/设置上报 AT+CSQ
+	SignalExt   string // 查询扩展信号质量 AT+CESQ
+	ServingCell string // 查询服务小区信息 AT+CPSI
+	Bands       string // 查询支持的频段（厂商扩展，无标准命令，默认为空）
 
 	// SIM 卡管理
 	SIMStatus string // 查询/验证 SIM 卡状态 AT+CPIN
@@ -63,6 +68,10 @@ type CommandSet struct {
 	CallState string // 查询通话状态 AT+CLCC
 	CallWait  string // 查询/设置呼叫等待 AT+CCWA
 	CallFWD   string // 查询/设置呼叫转移 AT+CCFC
+	CallColp  string // 查询/设置连接线号码显示 AT+COLP
+
+	// 补充业务
+	USSD string // 发送 USSD 请求 AT+CUSD
 
 	// 通知管理
 	NetworkRegNotify string // 查询/设置网络注册通知 AT+CREG
@@ -82,6 +91,7 @@ func DefaultCommandSet() *CommandSet {
 		SaveSettings: "AT&W",
 		LoadProfile:  "AT&Z",
 		SaveProfile:  "AT&W",
+		Charset:      "AT+CSCS",
 
 		// 设备身份信息
 		IMEI:         "AT+CGSN",
@@ -97,7 +107,10 @@ func DefaultCommandSet() *CommandSet {
 		NetworkMode: "AT+CNMP",
 		NetworkReg:  "AT+CREG",
 		GPRSReg:     "AT+CGREG",
+		EPSReg:      "AT+CEREG",
 		Signal:      "AT+CSQ",
+		SignalExt:   "AT+CESQ",
+		ServingCell: "AT+CPSI",
 
 		// SIM 卡管理
 		SIMStatus: "AT+CPIN",
@@ -134,6 +147,10 @@ func DefaultCommandSet() *CommandSet {
 		CallState: "AT+CLCC",
 		CallWait:  "AT+CCWA",
 		CallFWD:   "AT+CCFC",
+		CallColp:  "AT+COLP",
+
+		// 补充业务
+		USSD: "AT+CUSD",
 
 		// 通知管理
 		NetworkRegNotify: "AT+CREG",
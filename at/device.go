@@ -2,6 +2,8 @@ package at
 
 import (
 	"bufio"
+	"context"
+	"errors"
 	"fmt"
 	"io"
 	"log"
@@ -11,6 +13,12 @@ import (
 	"time"
 )
 
+// ErrDeviceClosed 设备连接已关闭
+var ErrDeviceClosed = errors.New("device closed")
+
+// ErrCommandTimeout 等待命令响应超时
+var ErrCommandTimeout = errors.New("command timeout")
+
 // 端口接口
 type Port interface {
 	Read(buf []byte) (int, error)   // 读取数据
@@ -21,11 +29,14 @@ type Port interface {
 
 // 配置参数
 type Config struct {
-	Timeout         time.Duration        // 超时时间
-	CommandSet      *CommandSet          // 自定义 AT 命令集，如果为 nil 则使用默认命令集
-	ResponseSet     *ResponseSet         // 自定义响应类型集，如果为 nil 则使用默认响应集
-	NotificationSet *NotificationSet     // 自定义通知类型集，如果为 nil 则使用默认通知集
-	Printf          func(string, ...any) // 日志输出函数，如果为 nil 则使用 log.Printf
+	Timeout         time.Duration             // 超时时间
+	CommandSet      *CommandSet               // 自定义 AT 命令集，如果为 nil 则使用默认命令集
+	ResponseSet     *ResponseSet              // 自定义响应类型集，如果为 nil 则使用默认响应集
+	NotificationSet *NotificationSet          // 自定义通知类型集，如果为 nil 则使用默认通知集
+	ResponseParsers map[string]ResponseParser // 自定义响应解析函数，按响应标签注册
+	SmsKeyTTL       time.Duration             // 短信幂等键有效期，如果为 0 则使用 10 分钟
+	ResolvePLMN     bool                      // GetNetworkInfo 是否在线解析数字格式运营商名称
+	Printf          func(string, ...any)      // 日志输出函数，如果为 nil 则使用 log.Printf
 }
 
 // 设备连接
@@ -35,17 +46,58 @@ type Device struct {
 	commands      CommandSet           // 使用的 AT 命令集
 	responses     ResponseSet          // 使用的响应类型集
 	responseChan  chan string          // 命令响应通道
+	done          chan struct{}        // 连接关闭信号
 	notifications NotificationSet      // 使用的通知类型集
 	urcHandler    UrcHandler           // 通知处理函数
 	printf        func(string, ...any) // 日志输出函数
-	closed        atomic.Bool          // 连接是否已关闭（原子操作保证并发安全）
-	cmd           atomic.Value         // 当前正在执行的命令
-	mu            sync.Mutex           // 保护命令发送的互斥锁
+	closed        *atomic.Bool         // 连接是否已关闭（原子操作保证并发安全）
+	cmd           *atomic.Value        // 当前正在执行的命令
+	mu            *sync.Mutex          // 保护命令发送的互斥锁
+	handlers      *handlerSet          // 订阅的通知处理函数
+	operFormat    *atomic.Int32        // 运营商名称格式，-1 表示使用设备当前格式
+	smsKeys       *smsKeySet           // 已发送的短信幂等键
+	plmnNames     *sync.Map            // 已解析的 PLMN 名称缓存，为 nil 时不解析
 }
 
 // 通知处理函数
 type UrcHandler func(string, map[int]string)
 
+// 自定义响应解析函数
+// lines: 命令响应中以对应标签开头的行，如 "+QCCID: 89860..."
+type ResponseParser func(lines []string) (any, error)
+
+// 新短信通知处理函数
+// mem: 短信存储位置 ["ME": 手机内存, "SM": SIM卡存储, "MT": 组合存储]
+// index: 短信索引
+type SmsReadyHandler func(mem string, index int)
+
+// 携带二进制数据的通知处理函数
+// label: 通知标签，如 "+CIPRXGOT"
+// param: 通知参数
+// data: 通知行之后的原始数据
+type RawUrcHandler func(label string, param map[int]string, data []byte)
+
+// 订阅的通知处理函数集合，在设备视图间共享
+type handlerSet struct {
+	mu       sync.RWMutex
+	smsReady SmsReadyHandler
+	waiters  map[*notifyWaiter]struct{}
+	parsers  map[string]ResponseParser
+	raws     map[string]rawUrc
+}
+
+// 携带二进制数据的通知订阅
+type rawUrc struct {
+	lenParam int           // 数据长度所在的参数索引
+	handler  RawUrcHandler // 处理函数
+}
+
+// 等待特定通知的接收者
+type notifyWaiter struct {
+	labels []string    // 等待的通知前缀
+	ch     chan string // 接收匹配的通知行
+}
+
 // New 创建一个新的设备连接实例
 func New(port Port, handler UrcHandler, config *Config) *Device {
 	if config == nil {
@@ -63,6 +115,9 @@ func New(port Port, handler UrcHandler, config *Config) *Device {
 	if config.NotificationSet == nil {
 		config.NotificationSet = DefaultNotificationSet()
 	}
+	if config.SmsKeyTTL == 0 {
+		config.SmsKeyTTL = 10 * time.Minute
+	}
 	if config.Printf == nil {
 		config.Printf = log.Printf
 	}
@@ -73,9 +128,30 @@ func New(port Port, handler UrcHandler, config *Config) *Device {
 		commands:      *config.CommandSet,
 		responses:     *config.ResponseSet,
 		responseChan:  make(chan string, 100),
+		done:          make(chan struct{}),
 		notifications: *config.NotificationSet,
 		urcHandler:    handler,
 		printf:        config.Printf,
+		closed:        &atomic.Bool{},
+		cmd:           &atomic.Value{},
+		mu:            &sync.Mutex{},
+		handlers: &handlerSet{
+			waiters: map[*notifyWaiter]struct{}{},
+			parsers: map[string]ResponseParser{},
+			raws:    map[string]rawUrc{},
+		},
+		operFormat: &atomic.Int32{},
+		smsKeys:    &smsKeySet{ttl: config.SmsKeyTTL, keys: map[string]smsKey{}},
+	}
+	dev.cmd.Store("")
+	dev.operFormat.Store(-1)
+	if config.ResolvePLMN {
+		dev.plmnNames = &sync.Map{}
+	}
+
+	// 注册自定义响应解析函数
+	for label, parser := range config.ResponseParsers {
+		dev.handlers.parsers[label] = parser
 	}
 
 	// 开始读取循环
@@ -84,6 +160,15 @@ func New(port Port, handler UrcHandler, config *Config) *Device {
 	return dev
 }
 
+// WithTimeout 返回使用指定超时时间的设备视图
+// 视图与原设备共享串口、互斥锁及读取循环，仅超时时间不同，不影响其他调用
+// 例如: dev.WithTimeout(60 * time.Second).SendCommand("AT+COPS=?")
+func (m *Device) WithTimeout(timeout time.Duration) *Device {
+	dev := *m
+	dev.timeout = timeout
+	return &dev
+}
+
 // IsOpen 链接状态
 func (m *Device) IsOpen() bool {
 	return !m.closed.Load()
@@ -96,20 +181,57 @@ func (m *Device) Close() error {
 		return nil // 已经关闭过了
 	}
 
-	close(m.responseChan)
+	// 先通知所有等待中的命令，再关闭串口
+	close(m.done)
 	return m.port.Close()
 }
 
+// OnSmsReady 订阅新短信通知（+CMTI），处理函数可获得存储位置及索引
+// 处理函数在独立的 goroutine 中执行，可直接调用 ReadSmsPdu 读取短信
+func (m *Device) OnSmsReady(handler SmsReadyHandler) {
+	m.handlers.mu.Lock()
+	defer m.handlers.mu.Unlock()
+	m.handlers.smsReady = handler
+}
+
+// OnRawNotification 订阅携带二进制数据的通知
+// 订阅后该通知行之后的原始数据按长度读取，不再按行拆分，以 []byte 交给处理函数
+// 标签无需在 NotificationSet 中，订阅后即按通知处理
+// label: 通知标签，如 "+CIPRXGOT"、"+QIURC"
+// lenParam: 数据长度所在的参数索引，如 "+CIPRXGOT: <link>,<len>" 为 1
+// handler: 处理函数，为 nil 时取消订阅
+func (m *Device) OnRawNotification(label string, lenParam int, handler RawUrcHandler) {
+	m.handlers.mu.Lock()
+	defer m.handlers.mu.Unlock()
+	if handler == nil {
+		delete(m.handlers.raws, label)
+		return
+	}
+	m.handlers.raws[label] = rawUrc{lenParam: lenParam, handler: handler}
+}
+
 // SendCommand 发送命令并等待响应
 func (m *Device) SendCommand(cmd string) ([]string, error) {
+	return m.sendCommand(context.Background(), cmd, nil)
+}
+
+// sendCommand 发送命令并等待响应
+// ctx: 取消时停止等待响应，迟到的响应在下一条命令发送前丢弃
+// written: 命令写入串口后、等待响应前调用，可为 nil
+func (m *Device) sendCommand(ctx context.Context, cmd string, written func()) ([]string, error) {
 	if m.closed.Load() {
-		return nil, fmt.Errorf("device closed")
+		return nil, ErrDeviceClosed
 	}
 
 	// 加锁保护
 	m.mu.Lock()
 	defer m.mu.Unlock()
 
+	// 等待锁期间连接可能已关闭
+	if m.closed.Load() {
+		return nil, ErrDeviceClosed
+	}
+
 	// 清空响应通道，避免收到残留响应
 	for len(m.responseChan) > 0 {
 		<-m.responseChan
@@ -128,8 +250,11 @@ func (m *Device) SendCommand(cmd string) ([]string, error) {
 	if err := m.writeString(cmd); err != nil {
 		return nil, err
 	}
+	if written != nil {
+		written()
+	}
 
-	return m.readResponse()
+	return m.readResponse(ctx)
 }
 
 // SendExpect 发送命令并期望特定响应
@@ -138,8 +263,11 @@ func (m *Device) SendExpect(cmd string, expected string) error {
 	if err != nil {
 		return err
 	}
+	return expectResponse(responses, expected)
+}
 
-	// 检查是否包含期望的响应
+// expectResponse 检查响应中是否包含期望的内容
+func expectResponse(responses []string, expected string) error {
 	for _, response := range responses {
 		if strings.Contains(response, expected) {
 			return nil
@@ -164,25 +292,66 @@ func (m *Device) SimpleQuery(cmd string) (string, error) {
 	return "", fmt.Errorf("no info found for %s", cmd)
 }
 
+// RegisterResponseParser 为指定响应标签注册自定义解析函数
+// label: 响应标签，如 "+QCCID"
+// 注册后可通过 QueryParsed 获取解析结果，适用于厂商扩展命令
+func (m *Device) RegisterResponseParser(label string, parser ResponseParser) {
+	m.handlers.mu.Lock()
+	defer m.handlers.mu.Unlock()
+	m.handlers.parsers[label] = parser
+}
+
+// QueryParsed 发送命令并使用已注册的解析函数解析响应
+// 解析函数由命令中的响应标签确定，如 "AT+QCCID" -> "+QCCID"
+func (m *Device) QueryParsed(cmd string) (any, error) {
+	label := getCommandResponseLabel(cmd)
+
+	m.handlers.mu.RLock()
+	parser := m.handlers.parsers[label]
+	m.handlers.mu.RUnlock()
+	if parser == nil {
+		return nil, fmt.Errorf("no parser registered for %q", label)
+	}
+
+	responses, err := m.SendCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range responses {
+		if strings.HasPrefix(line, label) {
+			lines = append(lines, line)
+		}
+	}
+	if len(lines) == 0 {
+		return nil, fmt.Errorf("no response matching %q found", label)
+	}
+	return parser(lines)
+}
+
 // readResponse 从响应通道读取响应
-func (m *Device) readResponse() ([]string, error) {
+func (m *Device) readResponse(ctx context.Context) ([]string, error) {
 	var responses []string
 	timeout := time.After(m.timeout)
 
 	for {
 		select {
-		case line, ok := <-m.responseChan:
-			if !ok {
-				return responses, fmt.Errorf("device closed")
-			}
+		case line := <-m.responseChan:
 			// 遇到终止响应，返回积累的行
 			responses = append(responses, line)
 			if m.responses.IsFinal(line) {
 				return responses, nil
 			}
 
+		case <-m.done:
+			return responses, ErrDeviceClosed
+
+		case <-ctx.Done():
+			return responses, ctx.Err()
+
 		case <-timeout:
-			return responses, fmt.Errorf("command timeout")
+			return responses, ErrCommandTimeout
 		}
 	}
 }
@@ -192,34 +361,50 @@ func (m *Device) readResponse() ([]string, error) {
 // readAndDispatch 从串口读取数据并分发
 func (m *Device) readAndDispatch() {
 	reader := bufio.NewReader(m.port)
+	next := "" // 拼接 USSD 响应时读到的下一条独立消息
 	for {
 		if m.closed.Load() {
 			return
 		}
 
-		// 读取一行数据
-		line, err := reader.ReadString('\n')
-		if err != nil {
-			if err != io.EOF {
-				m.printf("read error: %v", err)
+		line := next
+		next = ""
+		if line == "" {
+			// 读取一行数据
+			data, err := reader.ReadString('\n')
+			if err != nil {
+				if err != io.EOF {
+					m.printf("read error: %v", err)
+				}
+				time.Sleep(m.timeout / 2)
+				continue
+			}
+
+			// 去除空白字符
+			line = strings.TrimSpace(data)
+			if line == "" {
+				continue
 			}
-			time.Sleep(m.timeout / 2)
-			continue
 		}
 
-		// 去除空白字符
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
+		// 拼接被拆分为多行的 USSD 响应
+		ussd := m.notifications.USSD != "" && strings.HasPrefix(line, m.notifications.USSD)
+		if ussd {
+			line, next = m.readUSSDLines(reader, line)
 		}
 
 		// 处理通知消息
+		// USSD 结果可能在 OK 之后、命令结束之前到达，不能仅按当前命令区分
+		// 订阅了原始数据的通知不要求在通知集中
 		cmd := m.cmd.Load().(string)
-		if m.notifications.IsNotification(line, cmd) {
+		if (ussd && m.isUSSDResult(line)) || m.isRawNotification(line) || m.notifications.IsNotification(line, cmd) {
 			m.printf("receive urc: %s", line)
+			label, param := parseParam(line)
+			m.readRawNotification(reader, label, param)
 			if m.urcHandler != nil {
-				go m.urcHandler(parseParam(line))
+				go m.urcHandler(label, param)
 			}
+			m.dispatchNotification(line, label, param)
 			continue
 		}
 
@@ -234,10 +419,174 @@ func (m *Device) readAndDispatch() {
 	}
 }
 
+// waitNotification 注册通知等待者，返回接收通道及取消函数
+// labels: 等待的通知前缀，如 "+CUSD"、"NO CARRIER"
+func (m *Device) waitNotification(labels ...string) (<-chan string, func()) {
+	w := &notifyWaiter{labels: labels, ch: make(chan string, 1)}
+
+	m.handlers.mu.Lock()
+	m.handlers.waiters[w] = struct{}{}
+	m.handlers.mu.Unlock()
+
+	cancel := func() {
+		m.handlers.mu.Lock()
+		delete(m.handlers.waiters, w)
+		m.handlers.mu.Unlock()
+	}
+	return w.ch, cancel
+}
+
+// hasWaiter 检查是否有等待指定通知的等待者
+func (m *Device) hasWaiter(label string) bool {
+	m.handlers.mu.RLock()
+	defer m.handlers.mu.RUnlock()
+	for w := range m.handlers.waiters {
+		for _, prefix := range w.labels {
+			if prefix == label {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// isUSSDResult 检查 +CUSD 行是否为 USSD 结果而非 AT+CUSD? 的查询响应
+// 携带 <str> 参数或 SendUSSD 正在等待时视为 USSD 结果
+func (m *Device) isUSSDResult(line string) bool {
+	_, param := parseParam(line)
+	return len(param) >= 2 || m.hasWaiter(m.notifications.USSD)
+}
+
+// readUSSDLines 读取 +CUSD 响应的后续行，直到引号闭合
+// 遇到终止响应、通知、当前命令的响应或超过超时时间时停止拼接，
+// 按已读取的内容返回，并将读到的下一行返回给调用方继续处理
+func (m *Device) readUSSDLines(reader *bufio.Reader, line string) (string, string) {
+	deadline := time.Now().Add(m.timeout)
+	partial := ""
+	for i := 0; i < maxUSSDLines && !isUSSDComplete(line); {
+		data, err := reader.ReadString('\n')
+		partial += data
+		if err != nil {
+			if err != io.EOF || m.closed.Load() || time.Now().After(deadline) {
+				break
+			}
+			time.Sleep(m.timeout / 10)
+			continue
+		}
+
+		next := strings.TrimRight(partial, "\r\n")
+		partial = ""
+		if strings.TrimSpace(next) == "" {
+			continue
+		}
+		if time.Now().After(deadline) || m.isUSSDBoundary(next) {
+			m.printf("incomplete ussd: %s", line)
+			return line, strings.TrimSpace(next)
+		}
+		line += "\n" + next
+		i++
+	}
+	return line, strings.TrimSpace(partial)
+}
+
+// isUSSDBoundary 检查行是否不属于 USSD 响应文本
+// 引号未闭合时菜单文本可能以 "OK"、">"、"BUSY" 等开头，因此只认完整的结果码
+// 及 "<label>:" 格式的通知或当前命令响应，不按前缀匹配
+func (m *Device) isUSSDBoundary(line string) bool {
+	line = strings.TrimSpace(line)
+	rs := m.responses
+	for _, code := range []string{rs.OK, rs.Error, rs.CMEError, rs.CMSError, rs.CISError} {
+		if isResultLine(line, code) {
+			return true
+		}
+	}
+	for _, urc := range m.notifications.GetAllNotifications() {
+		if isResultLine(line, urc) {
+			return true
+		}
+	}
+	label := getCommandResponseLabel(m.cmd.Load().(string))
+	return isResultLine(line, label)
+}
+
+// isResultLine 检查行是否与结果码完全相同或为 "<code>: ..." 格式
+func isResultLine(line, code string) bool {
+	return code != "" && (line == code || strings.HasPrefix(line, code+":"))
+}
+
+// isRawNotification 检查行是否为已订阅原始数据的通知
+func (m *Device) isRawNotification(line string) bool {
+	label, param := parseParam(line)
+	if param == nil {
+		return false
+	}
+	m.handlers.mu.RLock()
+	defer m.handlers.mu.RUnlock()
+	_, ok := m.handlers.raws[label]
+	return ok
+}
+
+// readRawNotification 读取携带二进制数据的通知之后的原始数据
+func (m *Device) readRawNotification(reader *bufio.Reader, label string, param map[int]string) {
+	m.handlers.mu.RLock()
+	raw, ok := m.handlers.raws[label]
+	m.handlers.mu.RUnlock()
+	if !ok {
+		return
+	}
+
+	size := parseInt(param[raw.lenParam])
+	if size <= 0 {
+		return
+	}
+
+	data := make([]byte, size)
+	deadline := time.Now().Add(m.timeout)
+	for n := 0; n < size; {
+		k, err := reader.Read(data[n:])
+		n += k
+		if err == nil {
+			continue
+		}
+		if err != io.EOF || m.closed.Load() || time.Now().After(deadline) {
+			m.printf("read raw data error: %d of %d bytes, %v", n, size, err)
+			return
+		}
+		time.Sleep(m.timeout / 10)
+	}
+
+	m.printf("receive raw data: %s %d bytes", label, size)
+	go raw.handler(label, param, data)
+}
+
+// dispatchNotification 将通知分发给订阅的处理函数
+func (m *Device) dispatchNotification(line, label string, param map[int]string) {
+	m.handlers.mu.RLock()
+	defer m.handlers.mu.RUnlock()
+
+	// 通知等待者，仅投递第一条匹配的通知
+	for w := range m.handlers.waiters {
+		for _, prefix := range w.labels {
+			if prefix != "" && strings.HasPrefix(line, prefix) {
+				select {
+				case w.ch <- line:
+				default:
+				}
+				break
+			}
+		}
+	}
+
+	// 新短信通知: "+CMTI: <mem>,<index>"
+	if label == m.notifications.SmsReady && m.handlers.smsReady != nil && len(param) >= 2 {
+		go m.handlers.smsReady(param[0], parseInt(param[1]))
+	}
+}
+
 // writeString 写入数据到串口
 func (m *Device) writeString(data string) error {
 	if m.closed.Load() {
-		return fmt.Errorf("device closed")
+		return ErrDeviceClosed
 	}
 
 	m.printf("send command: %s", data)
@@ -50,6 +50,29 @@ func (m *Device) SaveProfile(profile int) error {
 	return m.SendExpect(cmd, "OK")
 }
 
+// GetCharset 查询 TE 字符集
+// 返回值: ["GSM": GSM 7 位默认字母表, "IRA": 国际参考字母表, "HEX": 十六进制, "UCS2": UCS2 十六进制, ...]
+func (m *Device) GetCharset() (string, error) {
+	responses, err := m.SendCommand(m.commands.Charset + "?")
+	if err != nil {
+		return "", err
+	}
+
+	// 响应格式: "+CSCS: <chset>"
+	param, err := parseResponse(m.commands.Charset, responses, 1)
+	if err != nil {
+		return "", err
+	}
+	return param[0], nil
+}
+
+// SetCharset 设置 TE 字符集
+// charset: 字符集 ["GSM", "IRA", "HEX", "UCS2", ...]
+func (m *Device) SetCharset(charset string) error {
+	cmd := fmt.Sprintf("%s=\"%s\"", m.commands.Charset, charset)
+	return m.SendExpect(cmd, "OK")
+}
+
 // ===== 设备状态 =====
 
 // GetBatteryLevel 查询电池电量及充电状态
@@ -1,6 +1,11 @@
 package at
 
-import "fmt"
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+)
 
 // ===== 语音通话 =====
 
@@ -9,6 +14,60 @@ func (m *Device) Dial(number string) error {
 	return m.SendExpect(m.commands.Dial+number, "OK")
 }
 
+// DialAndWait 拨打电话并等待接通
+// 先开启连接线号码显示（AT+COLP=1），拨号后等待 +COLP 通知
+// 返回接通的对方号码；遇到 NO CARRIER/BUSY/NO ANSWER/NO DIALTONE 时返回错误
+// ctx 同时限制拨号命令及接通通知的等待时间
+func (m *Device) DialAndWait(ctx context.Context, number string) (string, error) {
+	if err := m.SendExpect(m.commands.CallColp+"=1", "OK"); err != nil {
+		return "", err
+	}
+
+	ns := m.notifications
+	ch, cancel := m.waitNotification(ns.ConnectedLine, ns.NoCarrier, ns.Busy, ns.NoAnswer, ns.NoDialtone)
+	defer cancel()
+
+	// 部分设备在接通后才返回 OK，拨号超时不视为失败
+	if err := m.dial(ctx, number); err != nil && !errors.Is(err, ErrCommandTimeout) {
+		select {
+		case line := <-ch:
+			return m.parseDialResult(line)
+		default:
+			return "", err
+		}
+	}
+
+	select {
+	case line := <-ch:
+		return m.parseDialResult(line)
+	case <-m.done:
+		return "", ErrDeviceClosed
+	case <-ctx.Done():
+		return "", ctx.Err()
+	}
+}
+
+// dial 拨打电话，ctx 取消时停止等待命令响应
+func (m *Device) dial(ctx context.Context, number string) error {
+	responses, err := m.sendCommand(ctx, m.commands.Dial+number, nil)
+	if err != nil {
+		return err
+	}
+	return expectResponse(responses, "OK")
+}
+
+// parseDialResult 解析拨号结果通知
+func (m *Device) parseDialResult(line string) (string, error) {
+	// 通知格式: "+COLP: <number>,<type>"
+	// number: 接通的对方号码
+	// type: 号码类型 [129: 国内, 145: 国际]
+	if strings.HasPrefix(line, m.notifications.ConnectedLine) {
+		_, param := parseParam(line)
+		return param[0], nil
+	}
+	return "", fmt.Errorf("call failed: %s", line)
+}
+
 // Answer 接听电话
 func (m *Device) Answer() error {
 	return m.SendExpect(m.commands.Answer, "OK")
@@ -58,22 +117,23 @@ func (m *Device) GetCallState() ([]map[string]any, error) {
 	label := getCommandResponseLabel(m.commands.CallState)
 	for _, line := range responses {
 		respLabel, param := parseParam(line)
-		if respLabel == label && len(param) >= 7 {
-			// 响应格式: "+CLCC: <id>,<dir>,<status>,<mode>,<multip>,<number>,<type>"
+		if respLabel == label && len(param) >= 5 {
+			// 响应格式: "+CLCC: <id>,<dir>,<status>,<mode>,<multip>[,<number>,<type>[,<alpha>,...]]"
 			// id: 通话标识
 			// dir: 方向 [0: MO呼出, 1: MT呼入]
 			// status: 状态 [0: 活动中, 1: 保持中, 2: 拨号中, 3: 响铃中, 4: 来电中]
 			// mode: 模式 [0: 语音, 1: 数据, 2: 传真]
 			// multip: 多方通话
-			// number: 号码
-			// type: 号码类型 [129: 国际, 161: 国内]
+			// number: 号码（部分厂商会在其前插入额外字段）
+			// type: 号码类型 [129: 国内, 145: 国际, 161: 国内]
+			number, numType := parseCallNumber(param)
 			calls = append(calls, map[string]any{
 				"id":     parseInt(param[0]),
 				"dir":    parseInt(param[1]),
 				"status": parseInt(param[2]),
 				"mode":   parseInt(param[3]),
-				"number": param[5],
-				"type":   parseInt(param[6]),
+				"number": number,
+				"type":   numType,
 				"multip": parseInt(param[4]),
 			})
 		}
@@ -85,6 +145,35 @@ func (m *Device) GetCallState() ([]map[string]any, error) {
 	return calls, nil
 }
 
+// parseCallNumber 从 +CLCC 参数中提取号码及号码类型
+// 号码后紧跟号码类型（128-255），据此定位号码，兼容厂商插入的额外字段
+// 找不到时回退到标准位置
+func parseCallNumber(param map[int]string) (string, int) {
+	for i := 5; i+1 < len(param); i++ {
+		numType := parseInt(param[i+1])
+		if isPhoneNumber(param[i]) && numType >= 128 && numType <= 255 {
+			return param[i], numType
+		}
+	}
+	if len(param) >= 7 && (param[5] == "" || isPhoneNumber(param[5])) {
+		return param[5], parseInt(param[6])
+	}
+	return "", 0
+}
+
+// isPhoneNumber 检查字符串是否为电话号码（数字、+、*、#）
+func isPhoneNumber(s string) bool {
+	if s == "" {
+		return false
+	}
+	for i, c := range s {
+		if (c < '0' || c > '9') && c != '*' && c != '#' && (c != '+' || i != 0) {
+			return false
+		}
+	}
+	return true
+}
+
 // GetCallWait 查询呼叫等待状态
 func (m *Device) GetCallWait() (bool, error) {
 	responses, err := m.SendCommand(m.commands.CallWait + "?")
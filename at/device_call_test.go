@@ -0,0 +1,137 @@
+package at
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDialAndWait(t *testing.T) {
+	tests := []struct {
+		name   string
+		reply  string
+		number string
+		err    string
+	}{
+		{"connected", "OK\r\n\r\n+COLP: \"10086\",129", "10086", ""},
+		{"connected before ok", "+COLP: \"+8610086\",145\r\n\r\nOK", "+8610086", ""},
+		{"busy", "BUSY", "", "BUSY"},
+		{"no carrier", "OK\r\n\r\nNO CARRIER", "", "NO CARRIER"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, port := newTestDevice(t, map[string]string{
+				"AT+COLP=1": "OK",
+				"ATD10086;": tt.reply,
+			})
+
+			ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+			defer cancel()
+			number, err := dev.DialAndWait(ctx, "10086;")
+			if tt.err != "" {
+				if err == nil || !strings.Contains(err.Error(), tt.err) {
+					t.Fatalf("err = %v, want %q", err, tt.err)
+				}
+			} else if err != nil {
+				t.Fatal(err)
+			}
+			if number != tt.number {
+				t.Errorf("number = %q, want %q", number, tt.number)
+			}
+			if cmds := port.commands(); len(cmds) < 2 || cmds[0] != "AT+COLP=1" || cmds[1] != "ATD10086;" {
+				t.Errorf("commands = %q", cmds)
+			}
+		})
+	}
+
+	// 未接通时等待至上下文结束
+	dev, _ := newTestDevice(t, map[string]string{"AT+COLP=1": "OK", "ATD10086;": "OK"})
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	if _, err := dev.DialAndWait(ctx, "10086;"); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+}
+
+func TestDialAndWaitCommandTimeout(t *testing.T) {
+	// 接通后才返回 OK 的设备，拨号命令超时不视为失败
+	dev, port := newTestDevice(t, map[string]string{"AT+COLP=1": "OK", "ATD10086;": ""})
+	go func() {
+		time.Sleep(300 * time.Millisecond)
+		port.push("+COLP: \"10086\",129\r\n\r\nOK")
+	}()
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	number, err := dev.DialAndWait(ctx, "10086;")
+	if err != nil || number != "10086" {
+		t.Fatalf("got (%q, %v)", number, err)
+	}
+}
+
+func TestDialAndWaitContext(t *testing.T) {
+	port := newMockPort(map[string]string{"AT+COLP=1": "OK", "ATD10086;": ""})
+	dev := New(port, nil, &Config{
+		Timeout: 5 * time.Second,
+		Printf:  func(string, ...any) {},
+	})
+	defer dev.Close()
+
+	// 拨号命令等待期间同样受上下文限制
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	if _, err := dev.DialAndWait(ctx, "10086;"); err != context.DeadlineExceeded {
+		t.Fatalf("err = %v, want %v", err, context.DeadlineExceeded)
+	}
+	if elapsed := time.Since(start); elapsed > time.Second {
+		t.Fatalf("dial took %v", elapsed)
+	}
+}
+
+func TestParseCallNumber(t *testing.T) {
+	tests := []struct {
+		name    string
+		line    string
+		number  string
+		numType int
+	}{
+		{"standard", `+CLCC: 1,0,0,0,0,"+8613800138000",145`, "+8613800138000", 145},
+		{"standard alpha", `+CLCC: 1,1,4,0,0,"10086",129,"China, Mobile"`, "10086", 129},
+		{"vendor extra field", `+CLCC: 1,0,0,0,0,1,"13800138000",129`, "13800138000", 129},
+		{"vendor extra quoted", `+CLCC: 1,0,0,0,0,"",+8613800138000,145,""`, "+8613800138000", 145},
+		{"empty number", `+CLCC: 1,1,4,0,0,"",128`, "", 128},
+		{"no number", `+CLCC: 1,0,2,0,0`, "", 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			_, param := parseParam(tt.line)
+			number, numType := parseCallNumber(param)
+			if number != tt.number || numType != tt.numType {
+				t.Errorf("got (%q, %d), want (%q, %d)", number, numType, tt.number, tt.numType)
+			}
+		})
+	}
+}
+
+func TestGetCallState(t *testing.T) {
+	dev, _ := newTestDevice(t, map[string]string{
+		"AT+CLCC": "+CLCC: 1,0,0,0,0,\"+8613800138000\",145\r\n" +
+			"+CLCC: 2,1,4,0,1,1,\"13800138000\",129\r\n\r\nOK",
+	})
+
+	calls, err := dev.GetCallState()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(calls) != 2 {
+		t.Fatalf("calls = %v", calls)
+	}
+	if calls[0]["number"] != "+8613800138000" || calls[0]["type"] != 145 || calls[0]["multip"] != 0 {
+		t.Errorf("call 1 = %v", calls[0])
+	}
+	if calls[1]["number"] != "13800138000" || calls[1]["type"] != 129 || calls[1]["multip"] != 1 {
+		t.Errorf("call 2 = %v", calls[1])
+	}
+}
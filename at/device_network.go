@@ -1,11 +1,63 @@
 package at
 
-import "fmt"
+import (
+	"errors"
+	"fmt"
+
+	"github.com/rehiy/modem/utils"
+)
 
 // ===== 网络状态 =====
 
+// SetOperatorFormat 设置运营商名称格式
+// format: 格式 [0: 长字母数字, 1: 短字母数字, 2: 数字]
+// 设置后 GetOperator 查询前会先应用该格式，保证返回值格式一致
+func (m *Device) SetOperatorFormat(format int) error {
+	cmd := fmt.Sprintf("%s=3,%d", m.commands.Operator, format)
+	if err := m.SendExpect(cmd, "OK"); err != nil {
+		return err
+	}
+	m.operFormat.Store(int32(format))
+	return nil
+}
+
 // GetOperator 查询运营商信息
 func (m *Device) GetOperator() (int, int, string, int, error) {
+	// 应用已设置的运营商名称格式，避免设备重启等原因导致格式变化
+	if format := m.operFormat.Load(); format >= 0 {
+		cmd := fmt.Sprintf("%s=3,%d", m.commands.Operator, format)
+		if err := m.SendExpect(cmd, "OK"); err != nil {
+			return 0, 0, "", 0, err
+		}
+	}
+
+	return m.queryOperator()
+}
+
+// GetOperatorName 查询运营商名称（长字母数字格式）
+// 查询后恢复设备原有的格式，不影响 GetOperator 的返回值
+func (m *Device) GetOperatorName() (string, error) {
+	_, format, name, _, err := m.queryOperator()
+	if err != nil || format == 0 {
+		return name, err
+	}
+
+	cmd := fmt.Sprintf("%s=3,0", m.commands.Operator)
+	if err := m.SendExpect(cmd, "OK"); err != nil {
+		return "", err
+	}
+	_, _, name, _, err = m.queryOperator()
+
+	// 恢复原有格式
+	cmd = fmt.Sprintf("%s=3,%d", m.commands.Operator, format)
+	if rerr := m.SendExpect(cmd, "OK"); err == nil {
+		err = rerr
+	}
+	return name, err
+}
+
+// queryOperator 查询运营商信息（使用设备当前格式）
+func (m *Device) queryOperator() (int, int, string, int, error) {
 	responses, err := m.SendCommand(m.commands.Operator + "?")
 	if err != nil {
 		return 0, 0, "", 0, err
@@ -81,6 +133,23 @@ func (m *Device) GetGPRSStatus() (int, int, error) {
 	return parseInt(param[0]), parseInt(param[1]), nil
 }
 
+// GetEPSStatus 查询 EPS（LTE）注册状态及通知配置
+func (m *Device) GetEPSStatus() (int, int, error) {
+	responses, err := m.SendCommand(m.commands.EPSReg + "?")
+	if err != nil {
+		return 0, 0, err
+	}
+
+	// 响应格式: "+CEREG: <n>,<stat>"
+	// n: EPS 注册通知方式 [0: 禁用, 1: 启用, 2: 启用并显示位置信息]
+	// stat: 注册状态 [0: 未注册, 1: 已注册本地, 2: 未注册但在搜索, 3: 注册被拒绝, 4: 未知, 5: 已注册漫游]
+	param, err := parseResponse(m.commands.EPSReg, responses, 2)
+	if err != nil {
+		return 0, 0, err
+	}
+	return parseInt(param[0]), parseInt(param[1]), nil
+}
+
 // GetSignalQuality 查询信号质量
 func (m *Device) GetSignalQuality() (int, int, error) {
 	responses, err := m.SendCommand(m.commands.Signal)
@@ -98,6 +167,232 @@ func (m *Device) GetSignalQuality() (int, int, error) {
 	return parseInt(param[0]), parseInt(param[1]), nil
 }
 
+// ExtSignal 扩展信号质量，未知或不适用的值为 99（rxlev、ber）或 255
+type ExtSignal struct {
+	RxLev int // GSM 接收电平 [0-63]
+	BER   int // GSM 误码率 [0-7]
+	RSCP  int // UTRAN 接收码功率 [0-96]
+	EcNo  int // UTRAN 码片能量与噪声比 [0-49]
+	RSRQ  int // E-UTRAN 参考信号接收质量 [0-34]
+	RSRP  int // E-UTRAN 参考信号接收功率 [0-97]
+}
+
+// GetExtSignalQuality 查询扩展信号质量
+func (m *Device) GetExtSignalQuality() (*ExtSignal, error) {
+	responses, err := m.SendCommand(m.commands.SignalExt)
+	if err != nil {
+		return nil, err
+	}
+
+	// 响应格式: "+CESQ: <rxlev>,<ber>,<rscp>,<ecno>,<rsrq>,<rsrp>"
+	// rsrq: 转换公式: dB = -20 + rsrq/2
+	// rsrp: 转换公式: dBm = -141 + rsrp
+	param, err := parseResponse(m.commands.SignalExt, responses, 6)
+	if err != nil {
+		return nil, err
+	}
+	return &ExtSignal{
+		RxLev: parseInt(param[0]),
+		BER:   parseInt(param[1]),
+		RSCP:  parseInt(param[2]),
+		EcNo:  parseInt(param[3]),
+		RSRQ:  parseInt(param[4]),
+		RSRP:  parseInt(param[5]),
+	}, nil
+}
+
+// ServingCell 服务小区信息
+type ServingCell struct {
+	System    string         // 网络制式 ["NO SERVICE", "GSM", "WCDMA", "LTE", "NR5G_SA", ...]
+	Operation string         // 工作状态 ["Online", "Offline", "Low Power", ...]
+	PLMN      string         // 运营商代码 "<MCC>-<MNC>"
+	Area      string         // 位置区码或跟踪区码（LAC/TAC）
+	CellID    string         // 小区标识
+	Params    map[int]string // 原始参数，其余字段含义随网络制式变化
+}
+
+// GetServingCell 查询服务小区信息
+func (m *Device) GetServingCell() (*ServingCell, error) {
+	responses, err := m.SendCommand(m.commands.ServingCell + "?")
+	if err != nil {
+		return nil, err
+	}
+
+	// 响应格式: "+CPSI: <system>,<operation>[,<mcc>-<mnc>,<lac/tac>,<cellid>,...]"
+	// 无服务时仅返回前两个参数，例如 "+CPSI: NO SERVICE,Online"
+	param, err := parseResponse(m.commands.ServingCell, responses, 2)
+	if err != nil {
+		return nil, err
+	}
+	return &ServingCell{
+		System:    param[0],
+		Operation: param[1],
+		PLMN:      param[2],
+		Area:      param[3],
+		CellID:    param[4],
+		Params:    param,
+	}, nil
+}
+
+// NetworkInfo 网络状态汇总
+type NetworkInfo struct {
+	RegStatus int          // 注册状态，取 CREG/CGREG/CEREG 中最佳者 [0: 未注册, 1: 已注册本地, 2: 搜索中, 3: 被拒绝, 4: 未知, 5: 已注册漫游]
+	RegSource string       // 注册状态来源命令，全部查询失败时为空
+	Operator  string       // 运营商名称
+	PLMN      string       // 运营商数字代码，仅在设备返回数字格式时有效
+	AcT       int          // 接入技术，同 GetOperator
+	RSSI      int          // 信号强度 [0-31, 99: 未知]
+	BER       int          // 误码率 [0-7, 99: 未知]
+	Signal    *ExtSignal   // 扩展信号质量，设备不支持时为 nil
+	Cell      *ServingCell // 服务小区信息，设备不支持时为 nil
+}
+
+// GetNetworkInfo 一次性查询注册状态、运营商、信号质量及服务小区信息
+// 单项查询失败不影响其他项，仅在全部失败或设备关闭时返回错误
+// 运营商为数字格式且启用 Config.ResolvePLMN 时通过 utils.QueryPLMN 解析名称
+// 解析结果按设备缓存，解析失败则保留数字代码
+func (m *Device) GetNetworkInfo() (*NetworkInfo, error) {
+	info := &NetworkInfo{RegStatus: 4, RSSI: 99, BER: 99}
+
+	var errs []error
+	succeeded := false
+	failed := func(err error) bool {
+		if err != nil {
+			errs = append(errs, err)
+			return true
+		}
+		succeeded = true
+		return false
+	}
+
+	// 注册状态，取各域中最佳者
+	regs := []struct {
+		cmd   string
+		query func() (int, int, error)
+	}{
+		{m.commands.NetworkReg, m.GetNetworkStatus},
+		{m.commands.GPRSReg, m.GetGPRSStatus},
+		{m.commands.EPSReg, m.GetEPSStatus},
+	}
+	for _, reg := range regs {
+		_, stat, err := reg.query()
+		if errors.Is(err, ErrDeviceClosed) {
+			return nil, err
+		}
+		if failed(err) {
+			continue
+		}
+		if info.RegSource == "" || regRank(stat) > regRank(info.RegStatus) {
+			info.RegStatus, info.RegSource = stat, reg.cmd
+		}
+	}
+
+	// 运营商
+	_, format, oper, act, err := m.GetOperator()
+	if errors.Is(err, ErrDeviceClosed) {
+		return nil, err
+	}
+	if !failed(err) {
+		info.Operator, info.AcT = oper, act
+		if format == 2 && oper != "" {
+			info.PLMN = oper
+			if name := m.resolvePLMN(oper); name != "" {
+				info.Operator = name
+			}
+		}
+	}
+
+	// 信号质量
+	rssi, ber, err := m.GetSignalQuality()
+	if errors.Is(err, ErrDeviceClosed) {
+		return nil, err
+	}
+	if !failed(err) {
+		info.RSSI, info.BER = rssi, ber
+	}
+	signal, err := m.GetExtSignalQuality()
+	if errors.Is(err, ErrDeviceClosed) {
+		return nil, err
+	}
+	if !failed(err) {
+		info.Signal = signal
+	}
+
+	// 服务小区
+	cell, err := m.GetServingCell()
+	if errors.Is(err, ErrDeviceClosed) {
+		return nil, err
+	}
+	if !failed(err) {
+		info.Cell = cell
+	}
+
+	if !succeeded {
+		return nil, errors.Join(errs...)
+	}
+	return info, nil
+}
+
+// queryPLMN 在线查询运营商信息，测试时可替换
+var queryPLMN = utils.QueryPLMN
+
+// resolvePLMN 解析 PLMN 对应的运营商名称，未启用或解析失败时返回空字符串
+// 仅缓存成功的结果，失败时下次轮询重试
+func (m *Device) resolvePLMN(plmn string) string {
+	if m.plmnNames == nil {
+		return ""
+	}
+	if name, ok := m.plmnNames.Load(plmn); ok {
+		return name.(string)
+	}
+	op, err := queryPLMN(plmn)
+	if err != nil || op.Operator == "" {
+		return ""
+	}
+	m.plmnNames.Store(plmn, op.Operator)
+	return op.Operator
+}
+
+// regRank 注册状态优先级，已注册 > 搜索中 > 被拒绝 > 未注册/未知
+func regRank(stat int) int {
+	switch stat {
+	case 1, 5:
+		return 3
+	case 2:
+		return 2
+	case 3:
+		return 1
+	}
+	return 0
+}
+
+// BandList 各制式支持的频段列表
+type BandList struct {
+	GSM []int // 2G/3G 频段
+	LTE []int // 4G 频段
+	NR  []int // 5G 频段
+}
+
+// GetSupportedBands 查询设备支持的频段
+// 频段查询为厂商扩展命令，需由设备预设提供 CommandSet.Bands 及对应的响应解析函数
+// 解析函数需返回 *BandList
+func (m *Device) GetSupportedBands() ([]int, []int, []int, error) {
+	if m.commands.Bands == "" {
+		return nil, nil, nil, fmt.Errorf("bands query not supported")
+	}
+
+	result, err := m.QueryParsed(m.commands.Bands)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+
+	bands, ok := result.(*BandList)
+	if !ok {
+		return nil, nil, nil, fmt.Errorf("unexpected bands result %T", result)
+	}
+	return bands.GSM, bands.LTE, bands.NR, nil
+}
+
 // ===== 网络配置 =====
 
 // GetAPN 查询 APN 配置
@@ -0,0 +1,210 @@
+package at
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rehiy/modem/utils"
+)
+
+// newOperatorDevice 创建按当前 +COPS 格式返回运营商的模拟设备
+func newOperatorDevice(t *testing.T, format int) (*Device, *mockPort) {
+	dev, port := newTestDevice(t, nil)
+	names := map[int]string{0: "CHINA MOBILE", 1: "CMCC", 2: "46000"}
+	port.respond = func(cmd string) (string, bool) {
+		if v, ok := strings.CutPrefix(cmd, "AT+COPS=3,"); ok {
+			format = parseInt(v)
+			return "OK", true
+		}
+		if cmd == "AT+COPS?" {
+			return fmt.Sprintf("+COPS: 0,%d,\"%s\",7\r\n\r\nOK", format, names[format]), true
+		}
+		return "", false
+	}
+	return dev, port
+}
+
+func TestGetOperatorFormat(t *testing.T) {
+	dev, _ := newOperatorDevice(t, 0)
+
+	if err := dev.SetOperatorFormat(2); err != nil {
+		t.Fatal(err)
+	}
+	_, format, oper, act, err := dev.GetOperator()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != 2 || oper != "46000" || act != 7 {
+		t.Fatalf("got (%d, %q, %d)", format, oper, act)
+	}
+
+	// 查询名称不影响已设置的格式
+	name, err := dev.GetOperatorName()
+	if err != nil || name != "CHINA MOBILE" {
+		t.Fatalf("name = %q, %v", name, err)
+	}
+	if _, _, oper, _, _ := dev.GetOperator(); oper != "46000" {
+		t.Fatalf("operator after name = %q", oper)
+	}
+}
+
+func TestGetOperatorNameRestoresFormat(t *testing.T) {
+	// 未调用 SetOperatorFormat 时恢复设备原有格式
+	dev, port := newOperatorDevice(t, 1)
+
+	name, err := dev.GetOperatorName()
+	if err != nil || name != "CHINA MOBILE" {
+		t.Fatalf("name = %q, %v", name, err)
+	}
+	if _, _, oper, _, _ := dev.GetOperator(); oper != "CMCC" {
+		t.Fatalf("operator after name = %q", oper)
+	}
+
+	want := []string{"AT+COPS?", "AT+COPS=3,0", "AT+COPS?", "AT+COPS=3,1", "AT+COPS?"}
+	if got := port.commands(); strings.Join(got, "|") != strings.Join(want, "|") {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+
+	// 已是长字母数字格式时无需切换
+	dev, port = newOperatorDevice(t, 0)
+	if name, err := dev.GetOperatorName(); err != nil || name != "CHINA MOBILE" {
+		t.Fatalf("name = %q, %v", name, err)
+	}
+	if got := port.commands(); len(got) != 1 {
+		t.Fatalf("commands = %q", got)
+	}
+}
+
+func TestGetSupportedBands(t *testing.T) {
+	dev, _ := newTestDevice(t, map[string]string{
+		"AT+XBAND?": "+XBAND: 1,3\r\n+XBAND: 41\r\n\r\nOK",
+	})
+	if _, _, _, err := dev.GetSupportedBands(); err == nil {
+		t.Fatal("want error without bands command")
+	}
+
+	dev.commands.Bands = "AT+XBAND?"
+	dev.RegisterResponseParser("+XBAND", func(lines []string) (any, error) {
+		bands := &BandList{}
+		for _, line := range lines {
+			_, param := parseParam(line)
+			for i := 0; i < len(param); i++ {
+				bands.LTE = append(bands.LTE, parseInt(param[i]))
+			}
+		}
+		return bands, nil
+	})
+	gsm, lte, nr, err := dev.GetSupportedBands()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if gsm != nil || nr != nil || fmt.Sprint(lte) != "[1 3 41]" {
+		t.Fatalf("got (%v, %v, %v)", gsm, lte, nr)
+	}
+}
+
+// networkInfoReplies 返回 GetNetworkInfo 各项查询的预设响应
+func networkInfoReplies() map[string]string {
+	return map[string]string{
+		"AT+CREG?":  "+CREG: 0,2\r\n\r\nOK",
+		"AT+CGREG?": "+CGREG: 0,1\r\n\r\nOK",
+		"AT+CEREG?": "+CEREG: 0,5\r\n\r\nOK",
+		"AT+COPS?":  "+COPS: 0,2,\"46000\",7\r\n\r\nOK",
+		"AT+CSQ":    "+CSQ: 20,99\r\n\r\nOK",
+		"AT+CESQ":   "+CESQ: 99,99,255,255,20,50\r\n\r\nOK",
+		"AT+CPSI?":  "+CPSI: LTE,Online,460-00,0x1816,12345678\r\n\r\nOK",
+	}
+}
+
+// stubQueryPLMN 替换在线查询，返回调用计数
+func stubQueryPLMN(t *testing.T) *int {
+	calls := new(int)
+	orig := queryPLMN
+	queryPLMN = func(plmn string) (*utils.Operator, error) {
+		*calls++
+		return &utils.Operator{Operator: "China Mobile"}, nil
+	}
+	t.Cleanup(func() { queryPLMN = orig })
+	return calls
+}
+
+func TestGetNetworkInfo(t *testing.T) {
+	calls := stubQueryPLMN(t)
+	dev, _ := newTestDevice(t, networkInfoReplies())
+
+	info, err := dev.GetNetworkInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	// 同为已注册时取先查询到的 CGREG
+	if info.RegStatus != 1 || info.RegSource != "AT+CGREG" {
+		t.Errorf("reg = %d from %q", info.RegStatus, info.RegSource)
+	}
+	// 默认不在线解析运营商名称
+	if info.Operator != "46000" || info.PLMN != "46000" || info.AcT != 7 || *calls != 0 {
+		t.Errorf("operator = %q, plmn = %q, act = %d, lookups = %d", info.Operator, info.PLMN, info.AcT, *calls)
+	}
+	if info.RSSI != 20 || info.BER != 99 {
+		t.Errorf("csq = %d,%d", info.RSSI, info.BER)
+	}
+	if info.Signal == nil || info.Signal.RSRQ != 20 || info.Signal.RSRP != 50 {
+		t.Errorf("signal = %+v", info.Signal)
+	}
+	if info.Cell == nil || info.Cell.System != "LTE" || info.Cell.PLMN != "460-00" || info.Cell.CellID != "12345678" {
+		t.Errorf("cell = %+v", info.Cell)
+	}
+}
+
+func TestGetNetworkInfoPartial(t *testing.T) {
+	replies := networkInfoReplies()
+	delete(replies, "AT+CESQ")
+	delete(replies, "AT+CEREG?")
+	dev, _ := newTestDevice(t, replies)
+
+	info, err := dev.GetNetworkInfo()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if info.RegStatus != 1 || info.RegSource != "AT+CGREG" {
+		t.Errorf("reg = %d from %q", info.RegStatus, info.RegSource)
+	}
+	if info.Signal != nil || info.Cell == nil {
+		t.Errorf("signal = %+v, cell = %+v", info.Signal, info.Cell)
+	}
+}
+
+func TestGetNetworkInfoAllFailed(t *testing.T) {
+	dev, _ := newTestDevice(t, nil)
+
+	if info, err := dev.GetNetworkInfo(); err == nil {
+		t.Fatalf("info = %+v, want error", info)
+	}
+}
+
+func TestGetNetworkInfoResolvePLMN(t *testing.T) {
+	calls := stubQueryPLMN(t)
+	port := newMockPort(networkInfoReplies())
+	dev := New(port, nil, &Config{
+		Timeout:     200 * time.Millisecond,
+		ResolvePLMN: true,
+		Printf:      func(string, ...any) {},
+	})
+	t.Cleanup(func() { dev.Close() })
+
+	// 多次轮询仅查询一次
+	for i := 0; i < 2; i++ {
+		info, err := dev.GetNetworkInfo()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if info.Operator != "China Mobile" || info.PLMN != "46000" {
+			t.Fatalf("operator = %q, plmn = %q", info.Operator, info.PLMN)
+		}
+	}
+	if *calls != 1 {
+		t.Errorf("lookups = %d, want 1", *calls)
+	}
+}
@@ -1,13 +1,17 @@
 package at
 
 import (
+	"context"
+	"errors"
 	"fmt"
 	"sort"
 	"strings"
+	"sync"
 	"time"
 
 	"github.com/rehiy/modem/sms"
 	"github.com/rehiy/modem/sms/pdumode"
+	"github.com/rehiy/modem/sms/tpdu"
 )
 
 // SMS 短信信息
@@ -20,6 +24,19 @@ type Sms struct {
 	Status  string `json:"status"`  // PUD模式短信状态 [0: "REC UNREAD", 1: "REC READ", 2: "STO UNSENT", 3: "STO SENT"]
 }
 
+// 已发送的短信幂等键集合，在设备视图间共享
+type smsKeySet struct {
+	mu   sync.Mutex
+	ttl  time.Duration     // 幂等键有效期
+	keys map[string]smsKey // 幂等键及首次发送结果
+}
+
+// 幂等键记录
+type smsKey struct {
+	time time.Time // 发送时间
+	err  error     // 首次发送的结果
+}
+
 // SetSmsMode 设置短信模式
 // v [0: PDU 模式, 1: TEXT 模式]
 func (m *Device) SetSmsMode(v int) error {
@@ -91,7 +108,7 @@ func (m *Device) GetSmsCenter() (string, int, error) {
 
 	// 响应格式: "+CSCA: <number>,<tosca>"
 	// number: 短信中心号码
-	// tosca: 号码类型 [129: 国际, 145: 国内]
+	// tosca: 号码类型 [129: 国内, 145: 国际]
 	param, err := parseResponse(m.commands.SmsCenter+"?", responses, 2)
 	if err != nil {
 		return "", 0, err
@@ -101,8 +118,13 @@ func (m *Device) GetSmsCenter() (string, int, error) {
 }
 
 // SetSmsCenter 设置短信中心号码
+// 号码类型根据号码自动确定：以 "+" 开头为国际号码（145），否则为国内号码（129）
 func (m *Device) SetSmsCenter(number string) error {
-	cmd := fmt.Sprintf("%s=\"%s\"", m.commands.SmsCenter, number)
+	tosca := 129
+	if strings.HasPrefix(number, "+") {
+		tosca = 145
+	}
+	cmd := fmt.Sprintf("%s=\"%s\",%d", m.commands.SmsCenter, number, tosca)
 	return m.SendExpect(cmd, "OK")
 }
 
@@ -110,12 +132,18 @@ func (m *Device) SetSmsCenter(number string) error {
 // number: 接收方电话号码
 // message: 短信内容（支持中文）
 func (m *Device) SendSmsPdu(number, message string) error {
+	return m.sendSmsPdu(number, message, nil)
+}
+
+// sendSmsPdu 发送短信（PDU 模式）
+// written: 最后一个 PDU 写入串口后调用，可为 nil
+func (m *Device) sendSmsPdu(number, message string, written func()) error {
 	tpdus, err := sms.Encode([]byte(message), sms.To(number))
 	if err != nil {
 		return err
 	}
 
-	for _, p := range tpdus {
+	for i, p := range tpdus {
 		// 将 TPDU 序列化为字节数组
 		tpduBytes, err := p.MarshalBinary()
 		if err != nil {
@@ -134,20 +162,19 @@ func (m *Device) SendSmsPdu(number, message string) error {
 		// 发送 AT 命令（TPDU 长度不包含 SMSC 部分）
 		cmd := fmt.Sprintf("%s=%d\r", m.commands.SendSms, len(tpduBytes))
 		if resp, err := m.SendCommand(cmd); err != nil {
-			if !strings.Contains(err.Error(), "timeout") {
+			if !errors.Is(err, ErrCommandTimeout) {
 				m.printf("send sms command error: %s, %v", resp, err)
 			}
 		}
 		// 让子弹飞一会儿
 		time.Sleep(time.Second * 2)
 
-		// 临时延长超时
-		rdTimeout := m.timeout
-		m.timeout = time.Second * 15
-		defer func() { m.timeout = rdTimeout }()
-
-		// 发送 PDU 数据
-		if _, err := m.SendCommand(pduHex + "\x1A"); err != nil {
+		// 发送 PDU 数据（延长超时）
+		var done func()
+		if i == len(tpdus)-1 {
+			done = written
+		}
+		if _, err := m.WithTimeout(time.Second*15).sendCommand(context.Background(), pduHex+"\x1A", done); err != nil {
 			m.printf("send sms response error: %v", err)
 			return err
 		}
@@ -156,6 +183,41 @@ func (m *Device) SendSmsPdu(number, message string) error {
 	return nil
 }
 
+// SendSmsPduOnce 发送短信（PDU 模式），使用幂等键避免重试导致重复发送
+// key: 调用方提供的幂等键，有效期内重复调用不再发送，直接返回首次发送的结果
+// number: 接收方电话号码
+// message: 短信内容（支持中文）
+// 长短信的最后一个 PDU 写入串口后才记录幂等键，之前失败时重试会重新发送全部分片；
+// 之后发生的超时或错误同样记录，因为短信可能已发出；如需强制重发请更换幂等键
+func (m *Device) SendSmsPduOnce(key, number, message string) error {
+	if m.closed.Load() {
+		return ErrDeviceClosed
+	}
+
+	m.smsKeys.mu.Lock()
+	defer m.smsKeys.mu.Unlock()
+
+	// 清理过期的幂等键
+	now := time.Now()
+	for k, v := range m.smsKeys.keys {
+		if now.Sub(v.time) > m.smsKeys.ttl {
+			delete(m.smsKeys.keys, k)
+		}
+	}
+
+	if v, ok := m.smsKeys.keys[key]; ok {
+		m.printf("skip duplicate sms: %s", key)
+		return v.err
+	}
+
+	written := false
+	err := m.sendSmsPdu(number, message, func() { written = true })
+	if written {
+		m.smsKeys.keys[key] = smsKey{time: now, err: err}
+	}
+	return err
+}
+
 // ListSmsPdu 获取短信列表
 // stat: 短信状态 [0: REC UNREAD - 未读, 1: REC READ - 已读, 2: STO UNSENT - 未发送, 3: STO SENT - 已发送, 4: ALL - 所有]
 func (m *Device) ListSmsPdu(stat int) ([]Sms, error) {
@@ -173,7 +235,7 @@ func (m *Device) ListSmsPdu(stat int) ([]Sms, error) {
 	// 响应格式: "+CMGL: <index>,<stat>,[<alpha>],<length>"
 	// index: 短信索引
 	// stat: 状态 [0: REC UNREAD, 1: REC READ, 2: STO UNSENT, 3: STO SENT]
-	// alpha: 发送者名称
+	// alpha: 发送者名称（可能包含逗号，按引号拆分参数）
 	// length: 长度
 	// 下一行: PDU 十六进制数据
 	expectedLabel := getCommandResponseLabel(m.commands.ListSms)
@@ -194,17 +256,9 @@ func (m *Device) ListSmsPdu(stat int) ([]Sms, error) {
 		pduHex := responses[i]
 		i++
 
-		// 解析十六进制 PDU
-		pdu, err := pdumode.UnmarshalHexString(pduHex)
+		// 解析 PDU 数据
+		tpduMsg, err := m.unmarshalPdu(pduHex)
 		if err != nil {
-			m.printf("unmarshal pdu error: %v", err)
-			continue
-		}
-
-		// 从 PDU 中解析 TPDU
-		tpduMsg, err := sms.Unmarshal(pdu.TPDU)
-		if err != nil {
-			m.printf("unmarshal tpdu error: %v", err)
 			continue
 		}
 
@@ -249,6 +303,83 @@ func (m *Device) ListSmsPdu(stat int) ([]Sms, error) {
 	return result, nil
 }
 
+// ReadSmsPdu 读取指定存储位置及索引的短信
+// mem: 存储位置 ["ME": 手机内存, "SM": SIM卡存储, "MT": 组合存储, "": 不切换]
+// index: 短信索引
+// 长短信仅返回该索引对应分片的内容，读取后恢复原读取存储位置
+func (m *Device) ReadSmsPdu(mem string, index int) (*Sms, error) {
+	// 切换读取存储位置，与 +CMTI 通知中的存储位置保持一致
+	if mem != "" {
+		restore, err := m.selectReadStore(mem)
+		if err != nil {
+			return nil, err
+		}
+		defer restore()
+	}
+
+	cmd := fmt.Sprintf("%s=%d", m.commands.ReadSms, index)
+	responses, err := m.SendCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// 响应格式: "+CMGR: <stat>,[<alpha>],<length>"
+	// stat: 状态 [0: REC UNREAD, 1: REC READ, 2: STO UNSENT, 3: STO SENT]
+	// 下一行: PDU 十六进制数据
+	expectedLabel := getCommandResponseLabel(m.commands.ReadSms)
+	for i := 0; i+1 < len(responses); i++ {
+		label, param := parseParam(responses[i])
+		if label != expectedLabel || len(param) < 2 {
+			continue
+		}
+
+		tpduMsg, err := m.unmarshalPdu(responses[i+1])
+		if err != nil {
+			return nil, err
+		}
+
+		msgBytes, err := sms.Decode([]*tpdu.TPDU{tpduMsg})
+		if err != nil {
+			return nil, err
+		}
+
+		return &Sms{
+			Number:  tpduMsg.OA.Number(),
+			Text:    string(msgBytes),
+			Time:    tpduMsg.SCTS.Time.Format("2006/01/02 15:04:05"),
+			Index:   index,
+			Indices: []int{index},
+			Status:  param[0],
+		}, nil
+	}
+
+	return nil, fmt.Errorf("no response matching %q found", expectedLabel)
+}
+
+// selectReadStore 临时切换读取存储位置（mem1），返回恢复原存储位置的函数
+// 已选中该存储位置时不切换
+func (m *Device) selectReadStore(mem string) (func(), error) {
+	store, err := m.GetSmsStore()
+	if err != nil {
+		return nil, err
+	}
+	prev := store["mem1"].(string)
+	if prev == mem {
+		return func() {}, nil
+	}
+
+	cmd := fmt.Sprintf("%s=\"%s\"", m.commands.SmsStore, mem)
+	if err := m.SendExpect(cmd, "OK"); err != nil {
+		return nil, err
+	}
+	return func() {
+		cmd := fmt.Sprintf("%s=\"%s\"", m.commands.SmsStore, prev)
+		if err := m.SendExpect(cmd, "OK"); err != nil {
+			m.printf("restore sms store %s error: %v", prev, err)
+		}
+	}, nil
+}
+
 // DeleteSms 批量删除指定索引的短信
 // indices: 短信索引列表
 func (m *Device) DeleteSms(indices []int) error {
@@ -260,3 +391,111 @@ func (m *Device) DeleteSms(indices []int) error {
 	}
 	return nil
 }
+
+// FlushStorage 清空当前读取存储位置中的所有短信
+// progress: 进度回调 (已删除数量, 总数量)，可为 nil
+// 设备支持 AT+CMGD=1,4 时一次性清空，否则逐条删除，完成后校验存储是否为空
+func (m *Device) FlushStorage(progress func(done, total int)) error {
+	indices, err := m.listSmsIndices()
+	if err != nil {
+		return err
+	}
+
+	total := len(indices)
+	if progress == nil {
+		progress = func(done, total int) {}
+	}
+
+	if total > 0 {
+		if m.supportsDeleteFlag(4) {
+			cmd := fmt.Sprintf("%s=1,4", m.commands.DeleteSms)
+			if err := m.SendExpect(cmd, "OK"); err != nil {
+				return err
+			}
+			progress(total, total)
+		} else {
+			for i, index := range indices {
+				if err := m.DeleteSms([]int{index}); err != nil {
+					return err
+				}
+				progress(i+1, total)
+			}
+		}
+	}
+
+	// 校验存储是否已清空
+	store, err := m.GetSmsStore()
+	if err != nil {
+		return err
+	}
+	if used := store["used1"].(int); used != 0 {
+		return fmt.Errorf("storage not empty: %d messages remaining", used)
+	}
+	return nil
+}
+
+// listSmsIndices 获取当前读取存储位置中所有短信的索引
+func (m *Device) listSmsIndices() ([]int, error) {
+	cmd := fmt.Sprintf("%s=4", m.commands.ListSms)
+	responses, err := m.SendCommand(cmd)
+	if err != nil {
+		return nil, err
+	}
+
+	// 响应格式: "+CMGL: <index>,<stat>,[<alpha>],<length>"，下一行为 PDU 数据
+	var indices []int
+	label := getCommandResponseLabel(m.commands.ListSms)
+	for _, line := range responses {
+		if respLabel, param := parseParam(line); respLabel == label && len(param) > 0 {
+			indices = append(indices, parseInt(param[0]))
+		}
+	}
+	return indices, nil
+}
+
+// supportsDeleteFlag 检查设备是否支持指定的批量删除标志
+func (m *Device) supportsDeleteFlag(flag int) bool {
+	responses, err := m.SendCommand(m.commands.DeleteSms + "=?")
+	if err != nil {
+		return false
+	}
+
+	// 响应格式: "+CMGD: (<index>),(<delflag>)"，例如 "+CMGD: (1-50),(0-4)"
+	label := getCommandResponseLabel(m.commands.DeleteSms)
+	for _, line := range responses {
+		if !strings.HasPrefix(line, label) {
+			continue
+		}
+		// 仅有索引范围时不支持删除标志
+		start, end := strings.LastIndex(line, "("), strings.LastIndex(line, ")")
+		if strings.Count(line, "(") < 2 || end < start {
+			return false
+		}
+		for _, item := range strings.Split(line[start+1:end], ",") {
+			lo, hi, ok := strings.Cut(strings.TrimSpace(item), "-")
+			if !ok {
+				hi = lo
+			}
+			if parseInt(lo) <= flag && flag <= parseInt(hi) {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// unmarshalPdu 解析十六进制 PDU 数据并返回其中的 TPDU
+func (m *Device) unmarshalPdu(pduHex string) (*tpdu.TPDU, error) {
+	pdu, err := pdumode.UnmarshalHexString(pduHex)
+	if err != nil {
+		m.printf("unmarshal pdu error: %v", err)
+		return nil, err
+	}
+
+	tpduMsg, err := sms.Unmarshal(pdu.TPDU)
+	if err != nil {
+		m.printf("unmarshal tpdu error: %v", err)
+		return nil, err
+	}
+	return tpduMsg, nil
+}
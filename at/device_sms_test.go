@@ -0,0 +1,284 @@
+package at
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+// 来自 +31641600986 的短信 "How are you?"
+const testPdu = "07911326040000F0040B911346610089F60000208062917314080CC8F71D14969741F977FD07"
+
+func TestListSmsPduAlphaWithComma(t *testing.T) {
+	dev, _ := newTestDevice(t, map[string]string{
+		"AT+CMGL=4": `+CMGL: 3,1,"Smith, John",30` + "\r\n" + testPdu + "\r\n\r\nOK",
+	})
+
+	list, err := dev.ListSmsPdu(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := []Sms{{
+		Number:  "+31641600986",
+		Text:    "How are you?",
+		Time:    "2002/08/26 19:37:41",
+		Index:   3,
+		Indices: []int{3},
+		Status:  "1",
+	}}
+	if !reflect.DeepEqual(list, want) {
+		t.Fatalf("list = %+v, want %+v", list, want)
+	}
+}
+
+func TestSmsReadyReadsNotifiedStorage(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{
+		"AT+CPMS?":     `+CPMS: "ME",0,100,"ME",0,100,"SM",1,50` + "\r\n\r\nOK",
+		`AT+CPMS="SM"`: "+CPMS: 1,50,1,50,1,50\r\n\r\nOK",
+		`AT+CPMS="ME"`: "+CPMS: 0,100,0,100,1,50\r\n\r\nOK",
+		"AT+CMGR=3":    "+CMGR: 0,,30\r\n" + testPdu + "\r\n\r\nOK",
+	})
+
+	result := make(chan *Sms, 1)
+	dev.OnSmsReady(func(mem string, index int) {
+		msg, err := dev.ReadSmsPdu(mem, index)
+		if err != nil {
+			t.Error(err)
+		}
+		result <- msg
+	})
+	port.push(`+CMTI: "SM",3`)
+
+	select {
+	case msg := <-result:
+		if msg == nil || msg.Index != 3 || msg.Text != "How are you?" {
+			t.Fatalf("msg = %+v", msg)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("sms ready handler not called")
+	}
+
+	// 读取后恢复原读取存储位置
+	want := []string{"AT+CPMS?", `AT+CPMS="SM"`, "AT+CMGR=3", `AT+CPMS="ME"`}
+	if got := port.commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+}
+
+func TestReadSmsPduSelectedStorage(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{
+		"AT+CPMS?":  `+CPMS: "SM",1,50,"SM",1,50,"SM",1,50` + "\r\n\r\nOK",
+		"AT+CMGR=3": "+CMGR: 0,,30\r\n" + testPdu + "\r\n\r\nOK",
+	})
+
+	if _, err := dev.ReadSmsPdu("SM", 3); err != nil {
+		t.Fatal(err)
+	}
+
+	// 已选中该存储位置时不切换
+	want := []string{"AT+CPMS?", "AT+CMGR=3"}
+	if got := port.commands(); !reflect.DeepEqual(got, want) {
+		t.Fatalf("commands = %q, want %q", got, want)
+	}
+}
+
+// newSmsSendDevice 创建可发送短信的模拟设备，pdu 为 PDU 数据的响应
+func newSmsSendDevice(t *testing.T, pdu func() string) (*Device, *mockPort) {
+	dev, port := newTestDevice(t, nil)
+	port.respond = func(cmd string) (string, bool) {
+		switch {
+		case strings.HasPrefix(cmd, "AT+CMGS="):
+			return ">", true
+		case strings.HasSuffix(cmd, "\x1a"):
+			return pdu(), true
+		}
+		return "", false
+	}
+	return dev, port
+}
+
+// countSmsSent 统计写入的 PDU 数量
+func countSmsSent(port *mockPort) int {
+	n := 0
+	for _, cmd := range port.commands() {
+		if strings.HasSuffix(cmd, "\x1a") {
+			n++
+		}
+	}
+	return n
+}
+
+func TestSendSmsPduOnce(t *testing.T) {
+	dev, port := newSmsSendDevice(t, func() string { return "+CMGS: 12\r\n\r\nOK" })
+
+	for i := 0; i < 2; i++ {
+		if err := dev.SendSmsPduOnce("order-1", "+8613800138000", "hello"); err != nil {
+			t.Fatalf("send %d: %v", i, err)
+		}
+	}
+	if n := countSmsSent(port); n != 1 {
+		t.Fatalf("sent %d pdus, want 1", n)
+	}
+
+	// 其他幂等键正常发送
+	if err := dev.SendSmsPduOnce("order-2", "+8613800138000", "hello"); err != nil {
+		t.Fatal(err)
+	}
+	if n := countSmsSent(port); n != 2 {
+		t.Fatalf("sent %d pdus, want 2", n)
+	}
+}
+
+func TestSendSmsPduOnceAfterWrite(t *testing.T) {
+	// PDU 写入后连接中断，短信可能已发出，重试返回首次发送的结果
+	var dev *Device
+	dev, port := newSmsSendDevice(t, func() string {
+		go dev.Close()
+		return ""
+	})
+
+	if err := dev.SendSmsPduOnce("order-1", "+8613800138000", "hello"); err != ErrDeviceClosed {
+		t.Fatalf("err = %v, want %v", err, ErrDeviceClosed)
+	}
+	if got := dev.smsKeys.keys["order-1"].err; got != ErrDeviceClosed {
+		t.Fatalf("recorded err = %v, want %v", got, ErrDeviceClosed)
+	}
+	if err := dev.SendSmsPduOnce("order-1", "+8613800138000", "hello"); err != ErrDeviceClosed {
+		t.Fatalf("retry err = %v, want %v", err, ErrDeviceClosed)
+	}
+	if n := countSmsSent(port); n != 1 {
+		t.Fatalf("sent %d pdus, want 1", n)
+	}
+}
+
+func TestSendSmsPduOnceFirstResult(t *testing.T) {
+	dev, port := newSmsSendDevice(t, func() string { return "+CMGS: 12\r\n\r\nOK" })
+
+	// 重复调用返回首次发送的结果，而非成功
+	first := errors.New("command timeout")
+	dev.smsKeys.keys["order-1"] = smsKey{time: time.Now(), err: first}
+	if err := dev.SendSmsPduOnce("order-1", "+8613800138000", "hello"); err != first {
+		t.Fatalf("err = %v, want %v", err, first)
+	}
+	if n := countSmsSent(port); n != 0 {
+		t.Fatalf("sent %d pdus, want 0", n)
+	}
+}
+
+func TestSendSmsPduOnceIncomplete(t *testing.T) {
+	// 长短信第二个分片写入前连接中断，不记录幂等键
+	dev, port := newTestDevice(t, nil)
+	cmgs := 0
+	port.respond = func(cmd string) (string, bool) {
+		switch {
+		case strings.HasPrefix(cmd, "AT+CMGS="):
+			if cmgs++; cmgs == 2 {
+				go dev.Close()
+			}
+			return ">", true
+		case strings.HasSuffix(cmd, "\x1a"):
+			return "+CMGS: 12\r\n\r\nOK", true
+		}
+		return "", false
+	}
+
+	message := strings.Repeat("0123456789", 20)
+	if err := dev.SendSmsPduOnce("order-1", "+8613800138000", message); err != ErrDeviceClosed {
+		t.Fatalf("err = %v, want %v", err, ErrDeviceClosed)
+	}
+	if n := countSmsSent(port); n != 1 {
+		t.Fatalf("sent %d pdus, want 1", n)
+	}
+	if _, ok := dev.smsKeys.keys["order-1"]; ok {
+		t.Fatal("key recorded for incomplete message")
+	}
+}
+
+func TestFlushStorage(t *testing.T) {
+	list := "+CMGL: 1,1,,30\r\n" + testPdu + "\r\n" +
+		"+CMGL: 4,1,,30\r\n" + testPdu + "\r\n" +
+		"+CMGL: 7,0,,30\r\n" + testPdu + "\r\n\r\nOK"
+	empty := `+CPMS: "SM",0,50,"SM",0,50,"SM",0,50` + "\r\n\r\nOK"
+
+	tests := []struct {
+		name     string
+		test     string
+		deletes  []string
+		progress []int
+	}{
+		{"one shot", "+CMGD: (1-50),(0-4)", []string{"AT+CMGD=1,4"}, []int{3}},
+		{"per index", "+CMGD: (1-50),(0-1)", []string{"AT+CMGD=1", "AT+CMGD=4", "AT+CMGD=7"}, []int{1, 2, 3}},
+		{"index only", "+CMGD: (1-50)", []string{"AT+CMGD=1", "AT+CMGD=4", "AT+CMGD=7"}, []int{1, 2, 3}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, port := newTestDevice(t, map[string]string{
+				"AT+CMGL=4":   list,
+				"AT+CMGD=?":   tt.test + "\r\n\r\nOK",
+				"AT+CMGD=1,4": "OK",
+				"AT+CMGD=1":   "OK",
+				"AT+CMGD=4":   "OK",
+				"AT+CMGD=7":   "OK",
+				"AT+CPMS?":    empty,
+			})
+
+			var progress []int
+			err := dev.FlushStorage(func(done, total int) {
+				if total != 3 {
+					t.Errorf("total = %d, want 3", total)
+				}
+				progress = append(progress, done)
+			})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(progress, tt.progress) {
+				t.Errorf("progress = %v, want %v", progress, tt.progress)
+			}
+
+			var deletes []string
+			for _, cmd := range port.commands() {
+				if strings.HasPrefix(cmd, "AT+CMGD=") && cmd != "AT+CMGD=?" {
+					deletes = append(deletes, cmd)
+				}
+			}
+			if !reflect.DeepEqual(deletes, tt.deletes) {
+				t.Errorf("deletes = %q, want %q", deletes, tt.deletes)
+			}
+		})
+	}
+
+	// 删除后存储仍不为空
+	dev, _ := newTestDevice(t, map[string]string{
+		"AT+CMGL=4":   list,
+		"AT+CMGD=?":   "+CMGD: (1-50),(0-4)\r\n\r\nOK",
+		"AT+CMGD=1,4": "OK",
+		"AT+CPMS?":    `+CPMS: "SM",1,50,"SM",1,50,"SM",1,50` + "\r\n\r\nOK",
+	})
+	if err := dev.FlushStorage(nil); err == nil {
+		t.Fatal("want error for non-empty storage")
+	}
+}
+
+func TestSetSmsCenter(t *testing.T) {
+	tests := []struct {
+		number string
+		cmd    string
+	}{
+		{"+8613800100500", `AT+CSCA="+8613800100500",145`},
+		{"13800100500", `AT+CSCA="13800100500",129`},
+	}
+	for _, tt := range tests {
+		t.Run(tt.number, func(t *testing.T) {
+			dev, port := newTestDevice(t, map[string]string{tt.cmd: "OK"})
+			if err := dev.SetSmsCenter(tt.number); err != nil {
+				t.Fatal(err)
+			}
+			if cmds := port.commands(); len(cmds) != 1 || cmds[0] != tt.cmd {
+				t.Errorf("commands = %q, want %q", cmds, tt.cmd)
+			}
+		})
+	}
+}
@@ -0,0 +1,275 @@
+package at
+
+import (
+	"errors"
+	"io"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+)
+
+// mockPort 模拟串口，按写入的命令返回预设响应
+type mockPort struct {
+	mu      sync.Mutex
+	reader  *io.PipeReader
+	writer  *io.PipeWriter
+	replies map[string]string               // 命令 -> 响应，多行以 "\r\n" 分隔
+	delays  map[string]time.Duration        // 命令 -> 响应延迟
+	respond func(cmd string) (string, bool) // 动态响应，优先于 replies
+	sent    []string                        // 已写入的命令（去除结束符）
+}
+
+func newMockPort(replies map[string]string) *mockPort {
+	r, w := io.Pipe()
+	if replies == nil {
+		replies = map[string]string{}
+	}
+	return &mockPort{
+		reader:  r,
+		writer:  w,
+		replies: replies,
+		delays:  map[string]time.Duration{},
+	}
+}
+
+func (p *mockPort) Read(buf []byte) (int, error) {
+	return p.reader.Read(buf)
+}
+
+func (p *mockPort) Write(data []byte) (int, error) {
+	cmd := strings.TrimSpace(string(data))
+
+	p.mu.Lock()
+	p.sent = append(p.sent, cmd)
+	reply, ok := "", false
+	if p.respond != nil {
+		reply, ok = p.respond(cmd)
+	}
+	if !ok {
+		reply, ok = p.replies[cmd]
+	}
+	delay := p.delays[cmd]
+	p.mu.Unlock()
+
+	// 未预设的命令返回 ERROR，空响应表示不回复
+	if !ok {
+		reply = "ERROR"
+	}
+	if reply != "" {
+		go func() {
+			time.Sleep(delay)
+			p.push(reply)
+		}()
+	}
+	return len(data), nil
+}
+
+func (p *mockPort) Flush() error {
+	return nil
+}
+
+func (p *mockPort) Close() error {
+	return p.writer.Close()
+}
+
+// push 推送一行或多行数据，如 URC
+func (p *mockPort) push(lines string) {
+	p.pushRaw([]byte("\r\n" + lines + "\r\n"))
+}
+
+// pushRaw 推送原始数据
+func (p *mockPort) pushRaw(data []byte) {
+	p.writer.Write(data)
+}
+
+// setReply 设置命令的预设响应
+func (p *mockPort) setReply(cmd, reply string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.replies[cmd] = reply
+}
+
+// commands 返回已写入的命令
+func (p *mockPort) commands() []string {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return append([]string(nil), p.sent...)
+}
+
+// newTestDevice 创建连接到模拟串口的设备
+func newTestDevice(t *testing.T, replies map[string]string) (*Device, *mockPort) {
+	t.Helper()
+	port := newMockPort(replies)
+	dev := New(port, nil, &Config{
+		Timeout: 200 * time.Millisecond,
+		Printf:  func(string, ...any) {},
+	})
+	t.Cleanup(func() { dev.Close() })
+	return dev, port
+}
+
+func TestWithTimeout(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{"AT+COPS=?": "OK"})
+	port.delays["AT+COPS=?"] = 500 * time.Millisecond
+
+	// 延长超时仅作用于视图
+	if _, err := dev.WithTimeout(2 * time.Second).SendCommand("AT+COPS=?"); err != nil {
+		t.Fatalf("scoped command: %v", err)
+	}
+	if dev.timeout != 200*time.Millisecond {
+		t.Fatalf("device timeout changed to %v", dev.timeout)
+	}
+
+	// 原设备仍使用默认超时
+	start := time.Now()
+	_, err := dev.SendCommand("AT+COPS=?")
+	if !errors.Is(err, ErrCommandTimeout) {
+		t.Fatalf("default command: want timeout, got %v", err)
+	}
+	if elapsed := time.Since(start); elapsed > 400*time.Millisecond {
+		t.Fatalf("default command took %v", elapsed)
+	}
+}
+
+func TestQueryParsed(t *testing.T) {
+	type xyz struct {
+		Mode  int
+		Value string
+	}
+
+	dev, _ := newTestDevice(t, map[string]string{
+		"AT+XYZ?": `+XYZ: 2,"abc"` + "\r\n\r\nOK",
+	})
+	if _, err := dev.QueryParsed("AT+XYZ?"); err == nil {
+		t.Fatal("want error without registered parser")
+	}
+
+	dev.RegisterResponseParser("+XYZ", func(lines []string) (any, error) {
+		_, param := parseParam(lines[0])
+		return &xyz{Mode: parseInt(param[0]), Value: param[1]}, nil
+	})
+	result, err := dev.QueryParsed("AT+XYZ?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	got, ok := result.(*xyz)
+	if !ok || *got != (xyz{Mode: 2, Value: "abc"}) {
+		t.Fatalf("result = %#v", result)
+	}
+}
+
+func TestCloseCancelsPendingCommands(t *testing.T) {
+	port := newMockPort(map[string]string{"AT+COPS=?": ""}) // 不回复，命令一直等待
+	dev := New(port, nil, &Config{
+		Timeout: 10 * time.Second,
+		Printf:  func(string, ...any) {},
+	})
+
+	const n = 5
+	errs := make(chan error, n)
+	for i := 0; i < n; i++ {
+		go func() {
+			_, err := dev.SendCommand("AT+COPS=?")
+			errs <- err
+		}()
+	}
+
+	// 等待第一条命令写入，其余命令阻塞在互斥锁上
+	for deadline := time.Now().Add(time.Second); len(port.commands()) == 0; {
+		if time.Now().After(deadline) {
+			t.Fatal("command not sent")
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+
+	if err := dev.Close(); err != nil {
+		t.Fatal(err)
+	}
+	for i := 0; i < n; i++ {
+		select {
+		case err := <-errs:
+			if err != ErrDeviceClosed {
+				t.Errorf("err = %v, want %v", err, ErrDeviceClosed)
+			}
+		case <-time.After(time.Second):
+			t.Fatalf("%d commands still blocked after close", n-i)
+		}
+	}
+
+	if len(port.commands()) != 1 {
+		t.Errorf("commands = %q", port.commands())
+	}
+	if _, err := dev.SendCommand("AT"); err != ErrDeviceClosed {
+		t.Errorf("err after close = %v, want %v", err, ErrDeviceClosed)
+	}
+}
+
+func TestOnRawNotification(t *testing.T) {
+	dev, port := newTestDevice(t, nil)
+
+	type rawData struct {
+		label string
+		param map[int]string
+		data  []byte
+	}
+	raws := make(chan rawData, 1)
+	dev.OnRawNotification("+CIPRXGOT", 1, func(label string, param map[int]string, data []byte) {
+		raws <- rawData{label, param, data}
+	})
+	rings, cancel := dev.waitNotification("RING")
+	defer cancel()
+
+	// 数据中包含换行及二进制字节，且分两次到达
+	payload := []byte("ab\r\n\x00\xffOK\r\n")
+	port.pushRaw([]byte("\r\n+CIPRXGOT: 1,10\r\n"))
+	port.pushRaw(payload[:4])
+	time.Sleep(20 * time.Millisecond)
+	port.pushRaw(payload[4:])
+	port.push("RING")
+
+	select {
+	case raw := <-raws:
+		if raw.label != "+CIPRXGOT" || raw.param[0] != "1" || string(raw.data) != string(payload) {
+			t.Fatalf("got %q %v %q", raw.label, raw.param, raw.data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("raw handler not called")
+	}
+
+	// 原始数据之后的通知照常分发
+	select {
+	case <-rings:
+	case <-time.After(time.Second):
+		t.Fatal("notification after raw data not dispatched")
+	}
+}
+
+func TestOnRawNotificationCustomLabel(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{"AT+CSQ": "+CSQ: 20,99\r\n\r\nOK"})
+
+	// 标签不在通知集中
+	raws := make(chan []byte, 1)
+	dev.OnRawNotification("+QIURC", 2, func(label string, param map[int]string, data []byte) {
+		raws <- data
+	})
+
+	payload := []byte("GET /\r\nOK\r\n")
+	port.pushRaw([]byte("\r\n+QIURC: \"recv\",0,11\r\n"))
+	port.pushRaw(payload)
+
+	select {
+	case data := <-raws:
+		if string(data) != string(payload) {
+			t.Fatalf("data = %q", data)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("raw handler not called")
+	}
+
+	// 原始数据中的 OK 不得作为命令响应
+	responses, err := dev.SendCommand("AT+CSQ")
+	if err != nil || len(responses) != 2 || responses[0] != "+CSQ: 20,99" {
+		t.Fatalf("got (%q, %v)", responses, err)
+	}
+}
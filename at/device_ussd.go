@@ -0,0 +1,155 @@
+package at
+
+import (
+	"encoding/hex"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/rehiy/modem/sms/gsm7"
+	"github.com/rehiy/modem/sms/tpdu"
+	"github.com/rehiy/modem/sms/ucs2"
+)
+
+// ===== 补充业务 =====
+
+// USSD 响应最多拼接的后续行数
+const maxUSSDLines = 32
+
+// SendUSSD 发送 USSD 请求并等待网络响应
+// code: USSD 代码，例如 "*100#"
+// 返回 (状态, 响应文本)
+// 状态: [0: 无需进一步操作, 1: 需要进一步操作, 2: 网络终止]
+// 响应文本按 TE 字符集（AT+CSCS）解码:
+// "HEX" 时按 DCS 解码原始数据（7-bit 压缩、8-bit 或 UCS2），"UCS2" 时按 UCS2 解码，
+// 其他字符集视为设备已转换的可读文本，原样返回
+// 网络响应可能较慢，建议配合 WithTimeout 使用
+func (m *Device) SendUSSD(code string) (int, string, error) {
+	// 查询失败时按可读文本处理，不根据内容猜测编码
+	charset, err := m.GetCharset()
+	if err != nil {
+		m.printf("query charset error: %v", err)
+	}
+
+	// 先注册等待者，避免响应在命令返回前到达而丢失
+	ch, cancel := m.waitNotification(m.notifications.USSD)
+	defer cancel()
+
+	// +CUSD 无论在 OK 之前还是之后到达，均作为通知投递给等待者
+	cmd := fmt.Sprintf("%s=1,\"%s\",15", m.commands.USSD, code)
+	if err := m.SendExpect(cmd, "OK"); err != nil {
+		return 0, "", err
+	}
+
+	select {
+	case line := <-ch:
+		_, param := parseParam(line)
+		return parseUSSD(param, charset)
+	case <-m.done:
+		return 0, "", ErrDeviceClosed
+	case <-time.After(m.timeout):
+		return 0, "", fmt.Errorf("ussd response timeout")
+	}
+}
+
+// CancelUSSD 取消当前 USSD 会话
+func (m *Device) CancelUSSD() error {
+	return m.SendExpect(m.commands.USSD+"=2", "OK")
+}
+
+// parseUSSD 解析 USSD 响应参数
+// charset: TE 字符集，决定响应文本的解码方式
+func parseUSSD(param map[int]string, charset string) (int, string, error) {
+	// 响应格式: "+CUSD: <m>,[<str>,<dcs>]"
+	// m: 状态 [0: 无需进一步操作, 1: 需要进一步操作, 2: 网络终止, 4: 不支持, 5: 超时]
+	// str: 响应文本
+	// dcs: 数据编码方案（3GPP TS 23.038 小区广播 DCS）
+	if len(param) < 1 {
+		return 0, "", fmt.Errorf("invalid ussd response")
+	}
+	status := parseInt(param[0])
+	if len(param) < 2 {
+		return status, "", nil
+	}
+	dcs := 15
+	if len(param) > 2 {
+		dcs = parseInt(param[2])
+	}
+	return status, decodeUSSD(param[1], dcs, charset), nil
+}
+
+// decodeUSSD 按 TE 字符集及 DCS 解码 USSD 响应文本
+// 仅 "HEX"、"UCS2" 字符集下的文本为十六进制，其他字符集或无法解码时原样返回
+func decodeUSSD(text string, dcs int, charset string) string {
+	charset = strings.ToUpper(charset)
+	if charset != "HEX" && charset != "UCS2" {
+		return text
+	}
+
+	// 十六进制文本可能被设备折行
+	data, err := hex.DecodeString(strings.ReplaceAll(text, "\n", ""))
+	if err != nil || len(data) == 0 {
+		return text
+	}
+
+	// UCS2 字符集下设备已将文本转换为 UCS2，与 DCS 无关
+	if charset == "UCS2" {
+		return decodeUSSDUCS2(data, text)
+	}
+
+	switch ussdAlphabet(dcs) {
+	case tpdu.AlphaUCS2:
+		// 带语言前缀的 UCS2，前两个字节为语言代码
+		if dcs == 0x11 && len(data) > 2 {
+			data = data[2:]
+		}
+		return decodeUSSDUCS2(data, text)
+	case tpdu.Alpha8Bit:
+		return string(data)
+	default:
+		septets := gsm7.Unpack7BitUSSD(data, 0)
+		decoded, err := gsm7.Decode(septets)
+		if err != nil {
+			return text
+		}
+		return string(decoded)
+	}
+}
+
+// decodeUSSDUCS2 解码 UCS2 数据，失败时返回原文本
+func decodeUSSDUCS2(data []byte, text string) string {
+	decode := ucs2.Decode
+	if ucs2.IsLittleEndian(data) {
+		decode = ucs2.DecodeLE // 兼容输出小端序 UCS2 的设备
+	}
+	runes, err := decode(data)
+	if err != nil {
+		return text
+	}
+	return string(runes)
+}
+
+// ussdAlphabet 根据小区广播 DCS 返回字符集，见 3GPP TS 23.038 第 5 节
+func ussdAlphabet(dcs int) tpdu.Alphabet {
+	switch {
+	case dcs == 0x11: // 0001 0001 带语言前缀的 UCS2
+		return tpdu.AlphaUCS2
+	case dcs&0xc0 == 0x40, dcs&0xf0 == 0x90: // 01xx 通用编码, 1001 带 UDH 消息
+		switch (dcs >> 2) & 0x03 {
+		case 1:
+			return tpdu.Alpha8Bit
+		case 2:
+			return tpdu.AlphaUCS2
+		}
+	case dcs&0xf0 == 0xf0: // 1111 数据编码/消息类别
+		if dcs&0x04 != 0 {
+			return tpdu.Alpha8Bit
+		}
+	}
+	return tpdu.Alpha7Bit
+}
+
+// isUSSDComplete 检查 +CUSD 响应的引号是否闭合
+func isUSSDComplete(line string) bool {
+	return strings.Count(line, "\"")%2 == 0
+}
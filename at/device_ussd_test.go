@@ -0,0 +1,207 @@
+package at
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestDecodeUSSD(t *testing.T) {
+	tests := []struct {
+		name    string
+		text    string
+		dcs     int
+		charset string
+		want    string
+	}{
+		{"hex 7bit balance", "C2303BEC1E97413118285F0FBB1B", 15, "HEX", "Balance 10 yuan"},
+		{"hex ucs2 menu", "0031002E4F59989D67E58BE2000A0032002E6D4191CF67E58BE2", 72, "HEX", "1.余额查询\n2.流量查询"},
+		{"hex ucs2 le menu", "31002E00594F9D98E567E28B0A0032002E00416DCF91E567E28B", 72, "HEX", "1.余额查询\n2.流量查询"},
+		{"hex ucs2 language", "656E00480069", 0x11, "HEX", "Hi"},
+		{"hex 8bit", "48656C6C6F", 68, "HEX", "Hello"},
+		{"hex wrapped", "C2303BEC1E974131\n18285F0FBB1B", 15, "HEX", "Balance 10 yuan"},
+		{"ucs2 charset", "0031002E4F59989D67E58BE2000A0032002E6D4191CF67E58BE2", 15, "UCS2", "1.余额查询\n2.流量查询"},
+		{"gsm numeric", "1234", 15, "GSM", "1234"},
+		{"gsm hex lookalike", "ABCD", 15, "GSM", "ABCD"},
+		{"ira hex lookalike", "CAFE", 15, "IRA", "CAFE"},
+		{"unknown charset", "ABCD", 15, "", "ABCD"},
+		{"hex invalid", "Balance", 15, "HEX", "Balance"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := decodeUSSD(tt.text, tt.dcs, tt.charset); got != tt.want {
+				t.Errorf("decodeUSSD(%q, %d, %q) = %q, want %q", tt.text, tt.dcs, tt.charset, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSendUSSD(t *testing.T) {
+	const cmd = `AT+CUSD=1,"*100#",15`
+	tests := []struct {
+		name  string
+		reply string
+	}{
+		{"after ok", "OK\r\n+CUSD: 0,\"C2303BEC1E97413118285F0FBB1B\",15"},
+		{"before ok", "+CUSD: 0,\"C2303BEC1E97413118285F0FBB1B\",15\r\nOK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dev, _ := newTestDevice(t, map[string]string{
+				"AT+CSCS?": `+CSCS: "HEX"` + "\r\n\r\nOK",
+				cmd:        tt.reply,
+			})
+			status, text, err := dev.SendUSSD("*100#")
+			if err != nil {
+				t.Fatal(err)
+			}
+			if status != 0 || text != "Balance 10 yuan" {
+				t.Fatalf("got (%d, %q)", status, text)
+			}
+		})
+	}
+
+	// 网络响应在命令完成后到达
+	dev, port := newTestDevice(t, map[string]string{
+		"AT+CSCS?": `+CSCS: "GSM"` + "\r\n\r\nOK",
+		cmd:        "OK",
+	})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		port.push(`+CUSD: 1,"1234",15`)
+	}()
+	status, text, err := dev.SendUSSD("*100#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if status != 1 || text != "1234" {
+		t.Fatalf("got (%d, %q)", status, text)
+	}
+}
+
+func TestQueryUSSDMode(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{
+		"AT+CUSD?": "+CUSD: 1\r\n\r\nOK",
+		"AT+CSQ":   "+CSQ: 20,99\r\n\r\nOK",
+	})
+
+	// 查询响应不视为 USSD 结果
+	responses, err := dev.SendCommand("AT+CUSD?")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := []string{"+CUSD: 1", "OK"}; !reflect.DeepEqual(responses, want) {
+		t.Fatalf("responses = %q, want %q", responses, want)
+	}
+
+	// 其他命令执行期间到达的 USSD 结果作为通知分发
+	ch, cancel := dev.waitNotification("+CUSD")
+	defer cancel()
+	port.delays["AT+CSQ"] = 50 * time.Millisecond
+	go func() {
+		time.Sleep(20 * time.Millisecond)
+		port.push(`+CUSD: 0,"1234",15`)
+	}()
+	if _, _, err := dev.GetSignalQuality(); err != nil {
+		t.Fatal(err)
+	}
+	select {
+	case line := <-ch:
+		if line != `+CUSD: 0,"1234",15` {
+			t.Fatalf("urc = %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ussd not dispatched")
+	}
+}
+
+func TestSendUSSDMultiLine(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{
+		"AT+CSCS?":             `+CSCS: "GSM"` + "\r\n\r\nOK",
+		`AT+CUSD=1,"*100#",15`: "OK",
+	})
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		port.push("+CUSD: 1,\"1. Balance\r\n2. Data\r\n3. Exit\",15")
+	}()
+
+	status, text, err := dev.SendUSSD("*100#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := "1. Balance\n2. Data\n3. Exit"; status != 1 || text != want {
+		t.Fatalf("got (%d, %q), want (1, %q)", status, text, want)
+	}
+
+	// 菜单行以结果码或通知开头时不截断
+	menu := "Balance 10\r\n> Next\r\nOK to confirm\r\nBUSY hours 8-18\r\nERROR codes"
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		port.push("+CUSD: 1,\"" + menu + "\",15")
+	}()
+	status, text, err = dev.SendUSSD("*100#")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if want := strings.ReplaceAll(menu, "\r\n", "\n"); status != 1 || text != want {
+		t.Fatalf("got (%d, %q), want (1, %q)", status, text, want)
+	}
+}
+
+func TestUnterminatedUSSD(t *testing.T) {
+	dev, port := newTestDevice(t, map[string]string{
+		"AT+CSQ":  "+CSQ: 20,99\r\n\r\nOK",
+		"AT+CGMI": "Quectel\r\n\r\nOK",
+	})
+	urc := make(chan string, 1)
+	ch, cancel := dev.waitNotification("+CUSD")
+	defer cancel()
+	go func() { urc <- <-ch }()
+
+	// 未闭合的 USSD 响应不得吞掉后续命令的响应
+	port.push(`+CUSD: 0,"broken`)
+	time.Sleep(20 * time.Millisecond)
+	rssi, ber, err := dev.GetSignalQuality()
+	if err != nil || rssi != 20 || ber != 99 {
+		t.Fatalf("got (%d, %d, %v)", rssi, ber, err)
+	}
+	select {
+	case line := <-urc:
+		if line != `+CUSD: 0,"broken` {
+			t.Fatalf("urc = %q", line)
+		}
+	case <-time.After(time.Second):
+		t.Fatal("ussd not dispatched")
+	}
+
+	// 未闭合且无后续数据时，超时后恢复读取
+	port.push(`+CUSD: 0,"broken`)
+	time.Sleep(300 * time.Millisecond)
+	if model, err := dev.SimpleQuery("AT+CGMI"); err != nil || model != "Quectel" {
+		t.Fatalf("got (%q, %v)", model, err)
+	}
+}
+
+func TestUSSDLabelDisabled(t *testing.T) {
+	ns := DefaultNotificationSet()
+	ns.USSD = ""
+	port := newMockPort(map[string]string{
+		"AT+CGMI": "Acme \"Modem\r\n\r\nOK",
+	})
+	dev := New(port, nil, &Config{
+		Timeout:         200 * time.Millisecond,
+		NotificationSet: ns,
+		Printf:          func(string, ...any) {},
+	})
+	defer dev.Close()
+
+	// 未配置 USSD 标签时，含奇数个引号的行不做拼接
+	responses, err := dev.SendCommand("AT+CGMI")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(responses) != 2 || responses[0] != "Acme \"Modem" {
+		t.Fatalf("responses = %q", responses)
+	}
+}
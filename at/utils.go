@@ -39,7 +39,7 @@ func parseParam(line string) (string, map[int]string) {
 	if len(parts) == 2 {
 		param := map[int]string{}
 		label := strings.TrimSpace(parts[0])
-		group := strings.Split(strings.TrimSpace(parts[1]), ",")
+		group := splitParam(strings.TrimSpace(parts[1]))
 		for i, v := range group {
 			param[i] = strings.Trim(strings.TrimSpace(v), `"'`)
 		}
@@ -48,6 +48,25 @@ func parseParam(line string) (string, map[int]string) {
 	return line, nil
 }
 
+// splitParam 按逗号拆分参数，忽略双引号内的逗号
+// 例如: `1,0,"a,b",23` -> ["1", "0", `"a,b"`, "23"]
+func splitParam(s string) []string {
+	var group []string
+	quoted, start := false, 0
+	for i := 0; i < len(s); i++ {
+		switch s[i] {
+		case '"':
+			quoted = !quoted
+		case ',':
+			if !quoted {
+				group = append(group, s[start:i])
+				start = i + 1
+			}
+		}
+	}
+	return append(group, s[start:])
+}
+
 // getCommandResponseLabel 从 AT 命令中提取响应标签
 // 例如: "AT+CLCC" -> "+CLCC", "ATD" -> "" (ATD 不带前缀，返回空)
 func getCommandResponseLabel(cmd string) string {
@@ -0,0 +1,32 @@
+package at
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseParam(t *testing.T) {
+	tests := []struct {
+		name  string
+		line  string
+		label string
+		param map[int]string
+	}{
+		{"plain", "+CSQ: 20,99", "+CSQ", map[int]string{0: "20", 1: "99"}},
+		{"empty alpha", "+CMGL: 1,0,,24", "+CMGL", map[int]string{0: "1", 1: "0", 2: "", 3: "24"}},
+		{"alpha with comma", `+CMGL: 2,1,"Smith, John",24`, "+CMGL", map[int]string{0: "2", 1: "1", 2: "Smith, John", 3: "24"}},
+		{"quoted with colon", `+COPS: 0,0,"CMCC: 4G",7`, "+COPS", map[int]string{0: "0", 1: "0", 2: "CMCC: 4G", 3: "7"}},
+		{"no param", "OK", "OK", nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			label, param := parseParam(tt.line)
+			if label != tt.label {
+				t.Errorf("label = %q, want %q", label, tt.label)
+			}
+			if !reflect.DeepEqual(param, tt.param) {
+				t.Errorf("param = %v, want %v", param, tt.param)
+			}
+		})
+	}
+}
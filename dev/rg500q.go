@@ -0,0 +1,79 @@
+package dev
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/rehiy/modem/at"
+)
+
+type RG500Q struct {
+	CommandSet      *at.CommandSet
+	ResponseSet     *at.ResponseSet
+	NotificationSet *at.NotificationSet
+	ResponseParsers map[string]at.ResponseParser
+}
+
+func NewRG500Q() *RG500Q {
+	commandSet := at.DefaultCommandSet()
+	responseSet := at.DefaultResponseSet()
+	notificationSet := at.DefaultNotificationSet()
+
+	// 查询模块支持的频段
+	commandSet.Bands = `AT+QNWPREFCFG="policy_band"`
+
+	return &RG500Q{
+		CommandSet:      commandSet,
+		ResponseSet:     responseSet,
+		NotificationSet: notificationSet,
+		ResponseParsers: map[string]at.ResponseParser{
+			"+QNWPREFCFG": parsePolicyBand,
+		},
+	}
+}
+
+// parsePolicyBand 解析支持的频段
+// 响应格式: "+QNWPREFCFG: <band_type>,<band1>:<band2>:..."
+// band_type: ["gw_band": 2G/3G, "lte_band": 4G, "nsa_nr5g_band": 5G NSA, "nr5g_band": 5G SA]
+func parsePolicyBand(lines []string) (any, error) {
+	bands := &at.BandList{}
+	for _, line := range lines {
+		_, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		bandType, list, ok := strings.Cut(strings.TrimSpace(value), ",")
+		if !ok {
+			continue
+		}
+
+		var target *[]int
+		switch strings.Trim(bandType, `"`) {
+		case "gw_band":
+			target = &bands.GSM
+		case "lte_band":
+			target = &bands.LTE
+		case "nsa_nr5g_band", "nr5g_band":
+			target = &bands.NR
+		default:
+			continue
+		}
+
+		for _, v := range strings.Split(strings.TrimSpace(list), ":") {
+			band, err := strconv.Atoi(v)
+			if err != nil {
+				return nil, fmt.Errorf("invalid band %q in %q", v, line)
+			}
+			if !slices.Contains(*target, band) {
+				*target = append(*target, band)
+			}
+		}
+	}
+
+	slices.Sort(bands.GSM)
+	slices.Sort(bands.LTE)
+	slices.Sort(bands.NR)
+	return bands, nil
+}
@@ -0,0 +1,56 @@
+package dev
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/rehiy/modem/at"
+)
+
+func TestParsePolicyBand(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		want  *at.BandList
+		err   bool
+	}{
+		{
+			name: "all rats",
+			lines: []string{
+				`+QNWPREFCFG: "gw_band",1:2:5:8`,
+				`+QNWPREFCFG: "lte_band",1:3:5:8:34:38:39:40:41`,
+				`+QNWPREFCFG: "nsa_nr5g_band",41:78:79`,
+				`+QNWPREFCFG: "nr5g_band",1:28:41:78:79`,
+			},
+			want: &at.BandList{
+				GSM: []int{1, 2, 5, 8},
+				LTE: []int{1, 3, 5, 8, 34, 38, 39, 40, 41},
+				NR:  []int{1, 28, 41, 78, 79},
+			},
+		},
+		{
+			name: "unordered and unknown",
+			lines: []string{
+				`+QNWPREFCFG: "lte_band",41:3:1`,
+				`+QNWPREFCFG: "ue_usage_setting",1`,
+			},
+			want: &at.BandList{LTE: []int{1, 3, 41}},
+		},
+		{
+			name:  "invalid band",
+			lines: []string{`+QNWPREFCFG: "lte_band",1:x:3`},
+			err:   true,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parsePolicyBand(tt.lines)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if !tt.err && !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
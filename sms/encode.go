@@ -43,6 +43,9 @@ type Encoder struct {
 	// The template TPDU for encoding.
 	pdu tpdu.TPDU
 
+	// The DCS to be written verbatim, overriding the derived DCS.
+	rawDCS *byte
+
 	// MsgCount is the number of TPDUs encoded.
 	MsgCount tpdu.Counter
 
@@ -92,11 +95,10 @@ func (e Encoder) Encode(msg []byte, options ...EncoderOption) ([]tpdu.TPDU, erro
 	alpha, _ := e.pdu.DCS.Alphabet()
 	switch alpha {
 	case tpdu.Alpha8Bit, tpdu.AlphaUCS2:
-		return e.pdu.Segment(msg, sopts...), nil
 	default:
 		// encode as GSM7, or failing that UCS2...
-		d, udh, alpha := tpdu.EncodeUserData(msg, e.eopts...)
-		dcs, err := e.pdu.DCS.WithAlphabet(alpha)
+		d, udh, a := tpdu.EncodeUserData(msg, e.eopts...)
+		dcs, err := e.pdu.DCS.WithAlphabet(a)
 		if err != nil {
 			return nil, ErrDcsConflict
 		}
@@ -106,8 +108,17 @@ func (e Encoder) Encode(msg []byte, options ...EncoderOption) ([]tpdu.TPDU, erro
 		if udh != nil {
 			e.pdu.SetUDH(slices.Clone(append(e.pdu.UDH, udh...)))
 		}
-		return e.pdu.Segment(d, sopts...), nil
+		msg, alpha = d, a
+	}
+	// the raw DCS must agree with the alphabet used to encode the user data.
+	if e.rawDCS != nil {
+		a, err := tpdu.DCS(*e.rawDCS).Alphabet()
+		if err != nil || a != alpha {
+			return nil, ErrDcsConflict
+		}
+		e.pdu.SetDCS(*e.rawDCS)
 	}
+	return e.pdu.Segment(msg, sopts...), nil
 }
 
 // Counter is an implementation of the tpdu.Counter interface.
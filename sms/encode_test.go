@@ -0,0 +1,187 @@
+package sms_test
+
+import (
+	"bytes"
+	"encoding/hex"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/rehiy/modem/sms"
+	"github.com/rehiy/modem/sms/tpdu"
+	"github.com/rehiy/modem/sms/ucs2"
+)
+
+func TestEncodeRawDCS(t *testing.T) {
+	tests := []struct {
+		name    string
+		msg     []byte
+		options []sms.EncoderOption
+		dcs     tpdu.DCS
+		err     error
+		want    []byte // decoded message, if it differs from msg
+	}{
+		{"8bit class 1", []byte{1, 2, 3}, []sms.EncoderOption{sms.As8Bit, sms.WithRawDCS(0xf5)}, 0xf5, nil, nil},
+		{"7bit class 0", []byte("hello"), []sms.EncoderOption{sms.WithRawDCS(0xf0)}, 0xf0, nil, nil},
+		{"ucs2", []byte("你好"), []sms.EncoderOption{sms.WithRawDCS(0x19)}, 0x19, nil, nil},
+		{"ucs2 forced", ucs2.Encode([]rune("hello")), []sms.EncoderOption{sms.AsUCS2, sms.WithRawDCS(0x08)}, 0x08, nil, []byte("hello")},
+		{"ucs2 for 7bit", []byte("hello"), []sms.EncoderOption{sms.WithRawDCS(0x08)}, 0, sms.ErrDcsConflict, nil},
+		{"8bit for 7bit", []byte("hello"), []sms.EncoderOption{sms.WithRawDCS(0xf5)}, 0, sms.ErrDcsConflict, nil},
+		{"7bit for ucs2", []byte("你好"), []sms.EncoderOption{sms.WithRawDCS(0x00)}, 0, sms.ErrDcsConflict, nil},
+		{"reserved", []byte("hello"), []sms.EncoderOption{sms.WithRawDCS(0x84)}, 0, sms.ErrDcsConflict, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			options := append([]sms.EncoderOption{sms.To("+8613800138000")}, tt.options...)
+			pdus, err := sms.Encode(tt.msg, options...)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if err != nil {
+				return
+			}
+			if len(pdus) != 1 || pdus[0].DCS != tt.dcs {
+				t.Fatalf("pdus = %+v", pdus)
+			}
+
+			// the DCS is written verbatim, following the PID
+			b, err := pdus[0].MarshalBinary()
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !bytes.Contains(b, []byte{0x00, byte(tt.dcs)}) {
+				t.Errorf("pdu %X missing dcs %02X", b, byte(tt.dcs))
+			}
+
+			// and the user data decodes according to it
+			out, err := sms.Decode([]*tpdu.TPDU{&pdus[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			want := tt.want
+			if want == nil {
+				want = tt.msg
+			}
+			if !bytes.Equal(out, want) {
+				t.Errorf("decoded %q, want %q", out, want)
+			}
+		})
+	}
+}
+
+func TestEncodeDeliverRoundTrip(t *testing.T) {
+	ts := time.Date(2025, 3, 14, 15, 9, 26, 0, time.FixedZone("CST", 8*3600))
+	tests := []struct {
+		name     string
+		msg      string
+		segments int
+	}{
+		{"7bit concatenated", strings.Repeat("0123456789", 20), 2},
+		{"ucs2", "你好，世界", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			pdus, err := sms.Encode([]byte(tt.msg), sms.AsDeliver, sms.From("+8613800138000"), sms.At(ts))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pdus) != tt.segments {
+				t.Fatalf("segments = %d, want %d", len(pdus), tt.segments)
+			}
+
+			segs := make([]*tpdu.TPDU, len(pdus))
+			mref := -1
+			for i, p := range pdus {
+				b, err := p.MarshalBinary()
+				if err != nil {
+					t.Fatal(err)
+				}
+				seg, err := sms.Unmarshal(b, sms.AsMT)
+				if err != nil {
+					t.Fatal(err)
+				}
+				if seg.SmsType() != tpdu.SmsDeliver {
+					t.Errorf("type = %v", seg.SmsType())
+				}
+				if n := seg.OA.Number(); n != "+8613800138000" {
+					t.Errorf("oa = %q", n)
+				}
+				if !seg.SCTS.Equal(ts) {
+					t.Errorf("scts = %v, want %v", seg.SCTS.Time, ts)
+				}
+
+				// segments of a concatenated message share the mref
+				n, seqno, ref, ok := seg.ConcatInfo()
+				if tt.segments == 1 {
+					if ok {
+						t.Errorf("unexpected concat info (%d, %d, %d)", n, seqno, ref)
+					}
+				} else {
+					if mref == -1 {
+						mref = ref
+					}
+					if !ok || n != tt.segments || seqno != i+1 || ref != mref {
+						t.Errorf("concat info = (%d, %d, %d, %v)", n, seqno, ref, ok)
+					}
+				}
+				segs[i] = seg
+			}
+
+			out, err := sms.Decode(segs)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.msg {
+				t.Errorf("decoded %q, want %q", out, tt.msg)
+			}
+		})
+	}
+}
+
+func TestEncodeUCS2LeadingDigit(t *testing.T) {
+	// the alphabet is chosen from the text, not from the hex form of its
+	// UCS2 code units, so every leading hex digit must encode with DCS 0x08.
+	tests := []struct {
+		digit string
+		msg   string
+	}{
+		{"0", "а"},        // U+0430
+		{"0 ascii", "X中"}, // U+0058
+		{"1", "Ḁ"},        // U+1E00
+		{"2", "☃"},        // U+2603
+		{"3", "あ"},        // U+3042
+		{"4", "中"},        // U+4E2D
+		{"5", "字"},        // U+5B57
+		{"6", "文"},        // U+6587
+		{"7", "网"},        // U+7F51
+		{"8", "试"},        // U+8BD5
+		{"9", "验"},        // U+9A8C
+		{"A", "가"},        // U+AC00
+		{"B", "나"},        // U+B098
+		{"C", "어"},        // U+C5B4
+		{"D", "한"},        // U+D55C
+		{"E", "\ue000"},   // U+E000, private use
+		{"F", "Ａ"},        // U+FF21
+	}
+	for _, tt := range tests {
+		t.Run(tt.digit, func(t *testing.T) {
+			pdus, err := sms.Encode([]byte(tt.msg), sms.To("+8613800138000"))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if len(pdus) != 1 || pdus[0].DCS != 0x08 {
+				t.Fatalf("pdus = %+v", pdus)
+			}
+			if ud := pdus[0].UD; !strings.HasPrefix(strings.ToUpper(hex.EncodeToString(ud)), tt.digit[:1]) {
+				t.Errorf("ud = %X, want leading %s", ud, tt.digit[:1])
+			}
+			out, err := sms.Decode([]*tpdu.TPDU{&pdus[0]})
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(out) != tt.msg {
+				t.Errorf("decoded %q, want %q", out, tt.msg)
+			}
+		})
+	}
+}
@@ -104,12 +104,16 @@ func Pack7BitUSSD(u []byte, fillBits int) []byte {
 // The fillBits is the number of bits of pad at the beginning of the src, as
 // the packed septets may not start on an octet boundary.
 //
-// Any trailing CR is assumed to be filler if it ends on an octet boundary, or
-// if it starts on an octet boundary and the previous character is also CR.
+// Any trailing CR is assumed to be filler if it occupies the 7 spare bits at
+// the end of the final octet, or if it starts on an octet boundary and the
+// previous character is also CR.  A final octet that merely looks like a filler
+// CR, but has no spare bits, is part of the last septet and is retained.
 func Unpack7BitUSSD(p []byte, fillBits int) []byte {
 	u := Unpack7Bit(p, fillBits)
-	// remove any trailing filler
-	if len(p) > 1 && ((p[len(p)-1]>>1 == cr) || (p[len(p)-1] == cr && p[len(p)-2]>>1 == cr)) {
+	// remove any trailing filler - a filler CR in the final octet can only
+	// occupy the 7 spare bits at the end of the packed septets.
+	spare := (len(p)*8-fillBits)%7 == 0
+	if len(p) > 1 && ((spare && p[len(p)-1]>>1 == cr) || (p[len(p)-1] == cr && p[len(p)-2]>>1 == cr)) {
 		u = u[:len(u)-1]
 	}
 	return u
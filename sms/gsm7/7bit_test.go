@@ -0,0 +1,46 @@
+package gsm7_test
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/rehiy/modem/sms/gsm7"
+)
+
+func TestUnpack7BitUSSD(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		out  []byte
+	}{
+		// 8n-1 septets leave 7 spare bits, which carry a filler CR
+		{"filler cr", []byte{0x31, 0xd9, 0x8c, 0x56, 0xb3, 0xdd, 0x1a}, []byte("1234567")},
+		// 8n septets ending in CR have an extra CR octet appended
+		{"trailing cr", []byte{0x31, 0xd9, 0x8c, 0x56, 0xb3, 0xdd, 0x1a, 0x0d}, []byte("1234567\r")},
+		// the final octet matches CR, but has no spare bits to hold a filler
+		{"cr lookalike", []byte{0x6e, 0x1a}, []byte("n4")},
+		{"cr lookalike long", []byte{0xf9, 0x1b}, []byte("y7")},
+		{"no filler", []byte{0xe8, 0x32, 0x9b, 0xfd, 0x06}, []byte("hello")},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out := gsm7.Unpack7BitUSSD(tt.in, 0)
+			if !bytes.Equal(out, tt.out) {
+				t.Errorf("unpacked %q, want %q", out, tt.out)
+			}
+		})
+	}
+}
+
+func TestPack7BitUSSDRoundTrip(t *testing.T) {
+	patterns := []string{"n4", "y7", "1234567", "12345678", "123456\r", "1234567\r", "Balance 10 yuan"}
+	for _, p := range patterns {
+		t.Run(p, func(t *testing.T) {
+			packed := gsm7.Pack7BitUSSD([]byte(p), 0)
+			out := gsm7.Unpack7BitUSSD(packed, 0)
+			if !bytes.Equal(out, []byte(p)) {
+				t.Errorf("round trip %X gave %q", packed, out)
+			}
+		})
+	}
+}
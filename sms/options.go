@@ -1,6 +1,10 @@
 package sms
 
-import "github.com/rehiy/modem/sms/tpdu"
+import (
+	"time"
+
+	"github.com/rehiy/modem/sms/tpdu"
+)
 
 // EncoderOption is an optional mutator for the Encoder.
 type EncoderOption interface {
@@ -76,6 +80,25 @@ var (
 	WithDefaultCharset = CharsetOption{}
 )
 
+// WithRawDCS specifies a DCS to be written verbatim into the encoded TPDUs,
+// rather than being derived from the alphabet and template.
+//
+// The user data is still encoded using the alphabet selected by the template
+// or by the message content, and the encoding fails with ErrDcsConflict if the
+// alphabet indicated by the raw DCS differs from that alphabet.
+func WithRawDCS(dcs byte) EncoderOption {
+	return rawDCSOption{dcs}
+}
+
+type rawDCSOption struct {
+	dcs byte
+}
+
+func (o rawDCSOption) ApplyEncoderOption(e *Encoder) {
+	dcs := o.dcs
+	e.rawDCS = &dcs
+}
+
 // To specifies the DA for a SMS-SUBMIT TPDU.
 func To(number string) EncoderOption {
 	addr := tpdu.NewAddress(tpdu.FromNumber(number))
@@ -88,6 +111,13 @@ func From(number string) EncoderOption {
 	return templateOption{tpdu.WithOA(addr)}
 }
 
+// At specifies the SCTS for a SMS-DELIVER TPDU.
+//
+// All segments of a concatenated message share the same SCTS.
+func At(t time.Time) EncoderOption {
+	return templateOption{tpdu.WithSCTS(t)}
+}
+
 // AllCharsetsOption specifies that all charactersets are available for encoding.
 type AllCharsetsOption struct{}
 
@@ -2,6 +2,8 @@ package pdumode
 
 import (
 	"encoding/hex"
+
+	"github.com/rehiy/modem/sms/tpdu"
 )
 
 // PDU represents the PDU exchanged with the GSM modem.
@@ -74,3 +76,22 @@ func (p *PDU) MarshalHexString() (string, error) {
 	}
 	return hex.EncodeToString(b), nil
 }
+
+// CMGSLength returns the length expected by AT+CMGS for the PDU hex string.
+//
+// The length is the number of octets in the TPDU, i.e. the total length of the
+// PDU excluding the SMSC address, including its leading length octet.
+func CMGSLength(pduHex string) (int, error) {
+	b, err := hex.DecodeString(pduHex)
+	if err != nil {
+		return 0, err
+	}
+	if len(b) < 1 {
+		return 0, tpdu.NewDecodeError("length", 0, tpdu.ErrUnderflow)
+	}
+	smscLen := int(b[0])
+	if len(b) < 1+smscLen {
+		return 0, tpdu.NewDecodeError("addr", 1, tpdu.ErrUnderflow)
+	}
+	return len(b) - 1 - smscLen, nil
+}
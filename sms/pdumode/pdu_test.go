@@ -0,0 +1,34 @@
+package pdumode_test
+
+import (
+	"testing"
+
+	"github.com/rehiy/modem/sms/pdumode"
+)
+
+func TestCMGSLength(t *testing.T) {
+	tests := []struct {
+		name string
+		in   string
+		want int
+		err  bool
+	}{
+		{"empty smsc", "0001000B913166611111F6000004D4F29C0E", 17, false},
+		{"smsc", "07911326040000F0040B911346610089F60000208062917314080CC8F71D14969741F977FD07", 30, false},
+		{"smsc only", "07911326040000F0", 0, false},
+		{"empty", "", 0, true},
+		{"short smsc", "0791132604", 0, true},
+		{"not hex", "0G", 0, true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := pdumode.CMGSLength(tt.in)
+			if (err != nil) != tt.err {
+				t.Fatalf("err = %v, want error %v", err, tt.err)
+			}
+			if got != tt.want {
+				t.Errorf("length = %d, want %d", got, tt.want)
+			}
+		})
+	}
+}
@@ -1,5 +1,7 @@
 package tpdu
 
+import "time"
+
 // Option applies a construction option to a TPDU.
 type Option interface {
 	ApplyTPDUOption(*TPDU) error
@@ -37,6 +39,25 @@ func WithOA(addr Address) OAOption {
 	return OAOption{addr}
 }
 
+// SCTSOption specifies the SCTS for the TPDU.
+type SCTSOption struct {
+	t time.Time
+}
+
+// ApplyTPDUOption applies the SCTS to the TPDU.
+func (o SCTSOption) ApplyTPDUOption(t *TPDU) error {
+	t.SCTS = Timestamp{o.t}
+	return nil
+}
+
+// WithSCTS creates a SCTSOption to apply to a TPDU.
+//
+// The SCTS only has a resolution of seconds and a zone offset in quarter
+// hours, so finer detail in the time is lost when the TPDU is marshalled.
+func WithSCTS(t time.Time) SCTSOption {
+	return SCTSOption{t}
+}
+
 // UDHOption specifies the UDH for the TPDU.
 type UDHOption struct {
 	udh UserDataHeader
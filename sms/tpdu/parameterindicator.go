@@ -48,4 +48,11 @@ const (
 
 	// PiUDL indicates a TP-UDL field is present in the TPDU
 	PiUDL
+
+	// PiReserved are the reserved bits of the TP-PI, which the receiving
+	// entity shall ignore.
+	PiReserved = 0x78
+
+	// PiExtension indicates another TP-PI octet follows.
+	PiExtension = 0x80
 )
@@ -6,6 +6,16 @@ import (
 	"github.com/rehiy/modem/sms/bcd"
 )
 
+// CenturyPivot determines the century of the two digit years decoded from
+// SCTS timestamps.
+//
+// Years below the pivot are decoded into the 2000s, and the remainder into the
+// 1900s.  The default of 70 decodes 00-69 as 2000-2069 and 70-99 as 1970-1999,
+// so timestamps from 2070 onwards will be misdated.  Systems with a known
+// epoch may adjust the pivot, e.g. a pivot of 100 decodes all years into the
+// 2000s.
+var CenturyPivot = 70
+
 // Timestamp represents a SCTS timestamp, as defined in 3GPP TS 23.040 Section
 // 9.2.3.11.
 type Timestamp struct {
@@ -60,7 +70,7 @@ func (t *Timestamp) UnmarshalBinary(src []byte) error {
 		loc = time.FixedZone("SCTS", tzoffset)
 	}
 	year := i[0]
-	if year < 70 {
+	if year < CenturyPivot {
 		year += 2000
 	} else {
 		year += 1900
@@ -0,0 +1,44 @@
+package tpdu_test
+
+import (
+	"testing"
+
+	"github.com/rehiy/modem/sms/tpdu"
+)
+
+func TestTimestampCenturyPivot(t *testing.T) {
+	scts := func(yy byte) []byte {
+		return []byte{yy, 0x01, 0x61, 0x71, 0x03, 0x54, 0x00}
+	}
+	tests := []struct {
+		name  string
+		pivot int
+		in    []byte
+		year  int
+	}{
+		{"default 00", 70, scts(0x00), 2000},
+		{"default 69", 70, scts(0x96), 2069},
+		{"default 70", 70, scts(0x07), 1970},
+		{"default 99", 70, scts(0x99), 1999},
+		{"pivot 100 70", 100, scts(0x07), 2070},
+		{"pivot 100 99", 100, scts(0x99), 2099},
+		{"pivot 50 49", 50, scts(0x94), 2049},
+		{"pivot 50 50", 50, scts(0x05), 1950},
+	}
+	defer func(pivot int) { tpdu.CenturyPivot = pivot }(tpdu.CenturyPivot)
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			tpdu.CenturyPivot = tt.pivot
+			var ts tpdu.Timestamp
+			if err := ts.UnmarshalBinary(tt.in); err != nil {
+				t.Fatal(err)
+			}
+			if ts.Year() != tt.year {
+				t.Errorf("year = %d, want %d", ts.Year(), tt.year)
+			}
+			if ts.Month() != 10 || ts.Day() != 16 || ts.Hour() != 17 || ts.Minute() != 30 || ts.Second() != 45 {
+				t.Errorf("time = %v", ts)
+			}
+		})
+	}
+}
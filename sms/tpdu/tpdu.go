@@ -722,9 +722,21 @@ func (t *TPDU) unmarshalStatusReport(src []byte) error {
 }
 
 // unmarshal the optional fields at the end of the StatusReport TPDU.
+//
+// The optional fields are gated by the TP-PI, as per 3GPP TS 23.040 Section
+// 9.2.3.27.  Any extension octets following the TP-PI are skipped, and the
+// optional fields are ignored if any of the reserved bits are set, as some
+// SMSCs pad the TPDU with octets such as 0xff.
 func (t *TPDU) unmarshalSROptionals(ri int, src []byte) error {
-	t.PI = PI(src[ri])
+	pi := src[ri]
 	ri++
+	for ext := pi; ext&PiExtension != 0 && ri < len(src); ri++ {
+		ext = src[ri]
+	}
+	if pi&PiReserved != 0 {
+		return nil
+	}
+	t.PI = PI(pi) &^ PiExtension
 	if t.PI.PID() {
 		if len(src) <= ri {
 			return NewDecodeError("pid", ri, ErrUnderflow)
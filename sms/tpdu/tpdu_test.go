@@ -0,0 +1,53 @@
+package tpdu_test
+
+import (
+	"testing"
+
+	"github.com/rehiy/modem/sms/tpdu"
+)
+
+func TestUnmarshalStatusReport(t *testing.T) {
+	base := []byte{
+		0x06, 0x2a, // first octet, MR
+		0x0d, 0x91, 0x68, 0x31, 0x08, 0x10, 0x83, 0x00, 0xf0, // RA
+		0x62, 0x01, 0x61, 0x71, 0x03, 0x54, 0x23, // SCTS
+		0x62, 0x01, 0x61, 0x71, 0x03, 0x55, 0x23, // DT
+		0x00, // ST
+	}
+	tests := []struct {
+		name string
+		opt  []byte
+		pi   tpdu.PI
+		pid  byte
+		dcs  tpdu.DCS
+		udl  int
+	}{
+		{"no pi", nil, 0, 0, 0, 0},
+		{"dcs", []byte{0x02, 0x08}, 0x02, 0, 0x08, 0},
+		{"pid dcs udl", []byte{0x07, 0x7f, 0x00, 0x05, 0xe8, 0x32, 0x9b, 0xfd, 0x06}, 0x07, 0x7f, 0x00, 5},
+		{"extension", []byte{0x82, 0x00, 0x08}, 0x02, 0, 0x08, 0},
+		{"extension chain", []byte{0x82, 0x80, 0x00, 0x08}, 0x02, 0, 0x08, 0},
+		{"reserved", []byte{0x7a, 0x08}, 0, 0, 0, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			src := append(append([]byte{}, base...), tt.opt...)
+			var pdu tpdu.TPDU
+			if err := pdu.UnmarshalBinary(src); err != nil {
+				t.Fatal(err)
+			}
+			if pdu.SmsType() != tpdu.SmsStatusReport {
+				t.Fatalf("type = %v", pdu.SmsType())
+			}
+			if pdu.MR != 0x2a || pdu.RA.Number() != "+8613800138000" || pdu.ST != 0 {
+				t.Errorf("fixed fields: mr %d, ra %q, st %d", pdu.MR, pdu.RA.Number(), pdu.ST)
+			}
+			if pdu.SCTS.Second() != 45 || pdu.DT.Second() != 55 {
+				t.Errorf("timestamps: scts %v, dt %v", pdu.SCTS, pdu.DT)
+			}
+			if pdu.PI != tt.pi || pdu.PID != tt.pid || pdu.DCS != tt.dcs || len(pdu.UD) != tt.udl {
+				t.Errorf("optionals: pi %#x, pid %#x, dcs %#x, udl %d", pdu.PI, pdu.PID, pdu.DCS, len(pdu.UD))
+			}
+		})
+	}
+}
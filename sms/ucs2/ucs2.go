@@ -35,6 +35,41 @@ func Decode(src []byte) ([]rune, error) {
 	return dst, nil
 }
 
+// DecodeLE converts an array of little endian UCS2 characters into an array
+// of runes.
+//
+// 3GPP TS 23.038 requires UCS2 to be big endian, but some modems emit byte
+// swapped UCS2, which decodes to garbage using Decode.
+func DecodeLE(src []byte) ([]rune, error) {
+	if len(src)&0x01 == 0x01 {
+		return nil, ErrInvalidLength
+	}
+	be := make([]byte, len(src))
+	for i := 0; i < len(src)-1; i = i + 2 {
+		be[i], be[i+1] = src[i+1], src[i]
+	}
+	return Decode(be)
+}
+
+// IsLittleEndian returns true if the UCS2 characters appear to be byte
+// swapped.
+//
+// The heuristic relies on characters in the Latin range, which have a zero
+// high byte, so text composed entirely of characters outside that range, such
+// as CJK, cannot be detected and is assumed to be big endian.
+func IsLittleEndian(src []byte) bool {
+	var be, le int
+	for i := 0; i < len(src)-1; i = i + 2 {
+		switch {
+		case src[i] == 0 && src[i+1] != 0:
+			be++
+		case src[i] != 0 && src[i+1] == 0:
+			le++
+		}
+	}
+	return le > be
+}
+
 // Encode converts an array of UCS2 runes into an array of bytes, where pairs
 // of bytes (in Big Endian) represent a UCS2 character.
 func Encode(src []rune) []byte {
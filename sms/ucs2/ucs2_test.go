@@ -0,0 +1,56 @@
+package ucs2_test
+
+import (
+	"testing"
+
+	"github.com/rehiy/modem/sms/ucs2"
+)
+
+func TestDecodeLE(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		out  string
+		err  error
+	}{
+		{"empty", nil, "", nil},
+		{"latin", []byte{'H', 0, 'i', 0}, "Hi", nil},
+		{"mixed", []byte{'1', 0, '.', 0, 0x59, 0x4f, 0x9d, 0x98}, "1.余额", nil},
+		{"surrogate", []byte{0x3d, 0xd8, 0x00, 0xde}, "😀", nil},
+		{"odd length", []byte{'H', 0, 'i'}, "", ucs2.ErrInvalidLength},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			out, err := ucs2.DecodeLE(tt.in)
+			if err != tt.err {
+				t.Fatalf("err = %v, want %v", err, tt.err)
+			}
+			if string(out) != tt.out {
+				t.Errorf("decoded %q, want %q", string(out), tt.out)
+			}
+		})
+	}
+}
+
+func TestIsLittleEndian(t *testing.T) {
+	tests := []struct {
+		name string
+		in   []byte
+		out  bool
+	}{
+		{"empty", nil, false},
+		{"big endian latin", []byte{0, 'H', 0, 'i'}, false},
+		{"little endian latin", []byte{'H', 0, 'i', 0}, true},
+		{"big endian mixed", []byte{0, '1', 0, '.', 0x4f, 0x59, 0x98, 0x9d}, false},
+		{"little endian mixed", []byte{'1', 0, '.', 0, 0x59, 0x4f, 0x9d, 0x98}, true},
+		// CJK only text cannot be detected, so is assumed big endian
+		{"cjk only", []byte{0x59, 0x4f, 0x9d, 0x98}, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if out := ucs2.IsLittleEndian(tt.in); out != tt.out {
+				t.Errorf("IsLittleEndian(%X) = %v, want %v", tt.in, out, tt.out)
+			}
+		})
+	}
+}